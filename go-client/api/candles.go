@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/history"
+)
+
+// candleIntervals are the bucket sizes GET /candles accepts
+var candleIntervals = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// Candle counts of GET /candles
+const (
+	defaultCandles = 100
+	maxCandles     = 1000
+)
+
+// CandleData is one OHLC bucket. Prices are raw answers, like RoundData's.
+// Incomplete buckets may lack rounds, and have no prices if none is known.
+type CandleData struct {
+	Start      int64  `json:"start"`
+	Open       string `json:"open,omitempty"`
+	High       string `json:"high,omitempty"`
+	Low        string `json:"low,omitempty"`
+	Close      string `json:"close,omitempty"`
+	Count      int    `json:"count"`
+	Incomplete bool   `json:"incomplete,omitempty"`
+}
+
+// CandlesResponse holds the candles of a time range
+type CandlesResponse struct {
+	Interval string       `json:"interval"`
+	Candles  []CandleData `json:"candles"`
+}
+
+// GetCandlesHandler handles GET /candles and GET /feeds/{name}/candles.
+// interval is required; from and to are unix seconds and default to the
+// last 100 buckets. Buckets run from the one holding from to the one holding
+// to, and buckets without rounds repeat the previous close. Buckets where
+// rounds are missing, and could not be read from chain, are marked
+// incomplete rather than filled.
+func (api *API) GetCandlesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	values := r.URL.Query()
+	name := values.Get("interval")
+	interval, ok := candleIntervals[name]
+	if !ok {
+		http.Error(w, "interval must be one of 1m, 5m, 1h or 1d", http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseTimeParam(values, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to == nil {
+		now := time.Now()
+		to = &now
+	}
+	from, err := parseTimeParam(values, "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from == nil {
+		start := to.Add(-(defaultCandles - 1) * interval)
+		from = &start
+	}
+	if from.After(*to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	start := history.BucketStart(*from, interval)
+	end := history.BucketStart(*to, interval).Add(interval)
+	if count := end.Sub(start) / interval; count > maxCandles {
+		http.Error(w, fmt.Sprintf("range spans %d candles, at most %d are allowed", count, maxCandles), http.StatusBadRequest)
+		return
+	}
+
+	series, err := api.loadWindow(ctx, feed, start, end)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get rounds", err)
+		return
+	}
+
+	response := CandlesResponse{Interval: name, Candles: []CandleData{}}
+	for _, candle := range history.Candles(series.prev, series.points, series.gaps, start, end, interval) {
+		data := CandleData{
+			Start:      candle.Start.Unix(),
+			Count:      candle.Count,
+			Incomplete: candle.Incomplete,
+		}
+		if candle.Open != nil {
+			data.Open = candle.Open.String()
+			data.High = candle.High.String()
+			data.Low = candle.Low.String()
+			data.Close = candle.Close.String()
+		}
+		response.Candles = append(response.Candles, data)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// historyPoints parses the answers of stored rounds
+func historyPoints(rounds []*db.OracleRound) ([]history.Point, error) {
+	points := make([]history.Point, 0, len(rounds))
+	for _, round := range rounds {
+		p, err := history.NewPoint(round.RoundID, round.UpdatedAt, round.Answer)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
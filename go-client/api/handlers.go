@@ -1,28 +1,50 @@
 package api
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/114windd/oracle-client/internal/cache"
 	"github.com/114windd/oracle-client/internal/db"
-	"github.com/114windd/oracle-client/internal/reader"
+	"github.com/114windd/oracle-client/internal/events"
+	"github.com/114windd/oracle-client/internal/feeds"
+	"github.com/114windd/oracle-client/internal/metrics"
 	"github.com/114windd/oracle-client/internal/retry"
-	"github.com/114windd/oracle-client/internal/updater"
+	"github.com/114windd/oracle-client/internal/rpc"
 	"github.com/ethereum/go-ethereum/common"
 )
 
-// RoundData represents round data
+// RoundData represents round data. Price is Answer scaled by the feed's
+// decimals, omitted if they can't be read.
 type RoundData struct {
 	RoundID         uint64 `json:"roundId"`
 	Answer          string `json:"answer"`
+	Price           string `json:"price,omitempty"`
 	StartedAt       int64  `json:"startedAt"`
 	UpdatedAt       int64  `json:"updatedAt"`
 	AnsweredInRound uint64 `json:"answeredInRound"`
+
+	// Degraded marks the last known latest round, served while the RPC
+	// circuit breaker is open and neither the cache nor the database has it
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// LatestRoundData is the latest round of a feed and its age. Stale is set
+// once the age exceeds the feed's maximum price age.
+type LatestRoundData struct {
+	RoundData
+	AgeSeconds int64 `json:"ageSeconds"`
+	Stale      bool  `json:"stale"`
 }
 
 // UpdatePriceRequest represents update price request
@@ -30,12 +52,38 @@ type UpdatePriceRequest struct {
 	NewAnswer string `json:"newAnswer"`
 }
 
-// UpdatePriceResponse represents update price response
+// UpdatePriceResponse represents update price response. Round fields are
+// only set once the transaction is confirmed.
 type UpdatePriceResponse struct {
 	TxHash    string `json:"txHash"`
-	RoundID   uint64 `json:"roundId"`
-	Answer    string `json:"answer"`
-	UpdatedAt int64  `json:"updatedAt"`
+	Status    string `json:"status"`
+	RoundID   uint64 `json:"roundId,omitempty"`
+	Answer    string `json:"answer,omitempty"`
+	Price     string `json:"price,omitempty"`
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
+}
+
+// TransactionResponse represents the tracked status of an update transaction
+type TransactionResponse struct {
+	TxHash        string `json:"txHash"`
+	Feed          string `json:"feed"`
+	Chain         string `json:"chain"`
+	From          string `json:"from"`
+	Nonce         uint64 `json:"nonce"`
+	Answer        string `json:"answer"`
+	GasLimit      uint64 `json:"gasLimit"`
+	GasFeeCap     string `json:"gasFeeCap"`
+	GasTipCap     string `json:"gasTipCap"`
+	Replaces      string `json:"replaces,omitempty"`
+	ReplacedBy    string `json:"replacedBy,omitempty"`
+	Status        string `json:"status"`
+	BlockNumber   uint64 `json:"blockNumber,omitempty"`
+	BlockHash     string `json:"blockHash,omitempty"`
+	Confirmations uint64 `json:"confirmations"`
+	GasUsed       uint64 `json:"gasUsed,omitempty"`
+	Error         string `json:"error,omitempty"`
+	CreatedAt     int64  `json:"createdAt"`
+	UpdatedAt     int64  `json:"updatedAt"`
 }
 
 // HealthResponse represents health check response
@@ -44,39 +92,234 @@ type HealthResponse struct {
 	RPCConnected      bool   `json:"rpcConnected"`
 	RedisConnected    bool   `json:"redisConnected"`
 	PostgresConnected bool   `json:"postgresConnected"`
+
+	Feeds map[string]bool `json:"feeds"`
+
+	// DegradedFeeds lists why each degraded feed is: "stale" when its latest
+	// round is older than its maximum price age, and "incomplete_round" when
+	// answeredInRound < roundId. Any degraded feed makes Status "degraded".
+	DegradedFeeds map[string][]string `json:"degradedFeeds,omitempty"`
+
+	// Providers holds the state of each chain's RPC providers
+	Providers map[string][]ProviderHealth `json:"providers"`
+}
+
+// ProviderHealth is the state of one RPC provider. Block heights and scores
+// change constantly, so they are only reported by /providers.
+type ProviderHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Breaker string `json:"breaker"`
+}
+
+// FeedInfo describes a configured feed
+type FeedInfo struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	Chain     string `json:"chain"`
+	Updatable bool   `json:"updatable"`
 }
 
 // API holds dependencies
 type API struct {
-	reader  *reader.Reader
-	updater *updater.Updater
-	cache   *cache.Cache
-	db      *db.DB
+	feeds         *feeds.Registry
+	cache         cache.Cache
+	db            *db.DB
+	bus           *events.Bus
+	txWaitTimeout time.Duration
+	settings      *Settings
+
+	// Retry policies for contract reads and transaction submission
+	readRetry retry.Policy
+	txRetry   retry.Policy
+
+	// WebSocket limits
+	wsMaxTopics  int
+	wsSendBuffer int
+
+	healthMu   sync.Mutex
+	lastHealth *HealthResponse
+
+	// Latest round last served per feed, the fallback while RPC is unavailable
+	lastKnownMu sync.Mutex
+	lastKnown   map[string]RoundData
 }
 
-// New creates a new API instance
-func New(reader *reader.Reader, updater *updater.Updater, cache *cache.Cache, db *db.DB) *API {
+// New creates a new API instance. Streaming endpoints relay the events
+// published on bus. UpdatePriceHandler waits up to txWaitTimeout for the
+// update transaction to be confirmed. Contract reads are retried with
+// readRetry and update transactions with txRetry. Each WebSocket connection
+// may hold wsMaxTopics subscriptions and fall wsSendBuffer events behind.
+// Cache TTLs are read from settings on every request.
+func New(registry *feeds.Registry, cache cache.Cache, db *db.DB, bus *events.Bus, settings *Settings, txWaitTimeout time.Duration, readRetry, txRetry retry.Policy, wsMaxTopics, wsSendBuffer int) *API {
+	if wsMaxTopics <= 0 {
+		wsMaxTopics = 32
+	}
+	if wsSendBuffer <= 0 {
+		wsSendBuffer = 256
+	}
+
 	return &API{
-		reader:  reader,
-		updater: updater,
-		cache:   cache,
-		db:      db,
+		feeds:         registry,
+		cache:         cache,
+		db:            db,
+		bus:           bus,
+		txWaitTimeout: txWaitTimeout,
+		settings:      settings,
+		readRetry:     readRetry,
+		txRetry:       txRetry,
+		wsMaxTopics:   wsMaxTopics,
+		wsSendBuffer:  wsSendBuffer,
+		lastKnown:     make(map[string]RoundData),
+	}
+}
+
+// resolveFeed returns the feed named in the {name} path segment, or the
+// default feed for the un-namespaced routes. It writes a 404 if none matches.
+func (api *API) resolveFeed(w http.ResponseWriter, r *http.Request) (*feeds.Feed, bool) {
+	name := r.PathValue("name")
+	if name == "" {
+		if feed := api.feeds.Default(); feed != nil {
+			return feed, true
+		}
+		http.Error(w, "No feeds configured", http.StatusNotFound)
+		return nil, false
+	}
+
+	feed, ok := api.feeds.Get(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown feed: %s", name), http.StatusNotFound)
+		return nil, false
+	}
+	return feed, true
+}
+
+// serverError logs err with the request ID and reports it to the client.
+// Calls rejected by an open circuit breaker are reported as 503.
+func serverError(w http.ResponseWriter, r *http.Request, status int, msg string, err error) {
+	if errors.Is(err, rpc.ErrCircuitOpen) {
+		status = http.StatusServiceUnavailable
+	}
+	slog.ErrorContext(r.Context(), msg, "method", r.Method, "path", r.URL.Path, "status", status, "err", err)
+	http.Error(w, fmt.Sprintf("%s: %v", msg, err), status)
+}
+
+// rememberLatest records the latest round served for a feed
+func (api *API) rememberLatest(feed string, data RoundData) {
+	api.lastKnownMu.Lock()
+	defer api.lastKnownMu.Unlock()
+
+	api.lastKnown[feed] = data
+}
+
+// serveLastKnown writes the latest round last served for a feed, marked as
+// degraded, if RPC failed because the circuit breaker is open. It reports
+// whether it wrote a response.
+func (api *API) serveLastKnown(w http.ResponseWriter, r *http.Request, feed *feeds.Feed, strict bool, err error) bool {
+	if !errors.Is(err, rpc.ErrCircuitOpen) {
+		return false
+	}
+
+	api.lastKnownMu.Lock()
+	data, ok := api.lastKnown[feed.Name]
+	api.lastKnownMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	slog.WarnContext(r.Context(), "serving last known round while RPC is unavailable", "feed", feed.Name, "round", data.RoundID)
+	data.Degraded = true
+	writeLatest(w, feed, data, strict)
+	return true
+}
+
+// latestRound adds the age of the latest round of feed as of now
+func latestRound(feed *feeds.Feed, data RoundData, now time.Time) LatestRoundData {
+	age := max(now.Sub(time.Unix(data.UpdatedAt, 0)), 0)
+	return LatestRoundData{
+		RoundData:  data,
+		AgeSeconds: int64(age / time.Second),
+		Stale:      feed.MaxPriceAge > 0 && age > feed.MaxPriceAge,
 	}
 }
 
-// GetLatestPriceHandler handles GET /latestPrice
+// writeLatest writes the latest round of feed with its price and age. In
+// strict mode a stale round is refused with 503.
+func writeLatest(w http.ResponseWriter, feed *feeds.Feed, data RoundData, strict bool) {
+	latest := latestRound(feed, withPrice(feed, data), time.Now())
+	if latest.Stale && strict {
+		http.Error(w, fmt.Sprintf("Latest round %d of feed %s is %ds old, older than the maximum of %s", latest.RoundID, feed.Name, latest.AgeSeconds, feed.MaxPriceAge), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(latest)
+}
+
+// latestCacheKey returns the cache key for a feed's latest round
+func latestCacheKey(feed string) string {
+	return "feed:" + feed + ":latest"
+}
+
+// roundCacheKey returns the cache key for a single round of a feed
+func roundCacheKey(feed string, roundId uint64) string {
+	return fmt.Sprintf("feed:%s:round:%d", feed, roundId)
+}
+
+// ListFeedsHandler handles GET /feeds
+func (api *API) ListFeedsHandler(w http.ResponseWriter, r *http.Request) {
+	var response []FeedInfo
+	for _, feed := range api.feeds.All() {
+		response = append(response, FeedInfo{
+			Name:      feed.Name,
+			Address:   feed.Address.Hex(),
+			Chain:     feed.Chain,
+			Updatable: feed.Updater != nil,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetLatestPriceHandler handles GET /latestPrice and GET /feeds/{name}/latestPrice.
+// With ?strict=true, a round older than the feed's maximum price age is
+// refused with 503 instead of being served as stale.
 func (api *API) GetLatestPriceHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+	cacheKey := latestCacheKey(feed.Name)
+
+	strict := false
+	if value := r.URL.Query().Get("strict"); value != "" {
+		var err error
+		if strict, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid strict value", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Try cache first
-	if data, err := api.cache.Get(ctx, "latest"); err == nil && data != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(data)
+	if data, err := api.cache.Get(ctx, cacheKey); err == nil && data != nil {
+		response := RoundData{
+			RoundID:         data.RoundID,
+			Answer:          data.Answer,
+			StartedAt:       data.StartedAt,
+			UpdatedAt:       data.UpdatedAt,
+			AnsweredInRound: data.AnsweredInRound,
+		}
+		api.rememberLatest(feed.Name, response)
+		metrics.ResponseSources.WithLabelValues("latestPrice", "cache").Inc()
+		writeLatest(w, feed, response, strict)
 		return
 	}
 
 	// Try database
-	if dbData, err := api.db.GetLatest(ctx); err == nil && dbData != nil {
+	if dbData, err := api.db.GetLatest(ctx, feed.Name); err == nil && dbData != nil {
 		response := RoundData{
 			RoundID:         dbData.RoundID,
 			Answer:          dbData.Answer,
@@ -91,16 +334,17 @@ func (api *API) GetLatestPriceHandler(w http.ResponseWriter, r *http.Request) {
 			UpdatedAt:       response.UpdatedAt,
 			AnsweredInRound: response.AnsweredInRound,
 		}
-		api.cache.Set(ctx, "latest", cacheData, 10*time.Second)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		api.cache.Set(ctx, cacheKey, cacheData, api.settings.Load().LatestCacheTTL)
+		api.rememberLatest(feed.Name, response)
+		metrics.ResponseSources.WithLabelValues("latestPrice", "db").Inc()
+		writeLatest(w, feed, response, strict)
 		return
 	}
 
 	// Fallback to RPC with retry
 	var response RoundData
-	err := retry.Retry(ctx, func() error {
-		roundId, answer, startedAt, updatedAt, answeredInRound, err := api.reader.GetLatestRoundData(ctx)
+	err := api.readRetry.Do(ctx, func(ctx context.Context) error {
+		roundId, answer, startedAt, updatedAt, answeredInRound, err := feed.Reader.GetLatestRoundData(ctx)
 		if err != nil {
 			return err
 		}
@@ -115,9 +359,14 @@ func (api *API) GetLatestPriceHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get latest price: %v", err), http.StatusInternalServerError)
+		if api.serveLastKnown(w, r, feed, strict, err) {
+			metrics.ResponseSources.WithLabelValues("latestPrice", "last_known").Inc()
+			return
+		}
+		serverError(w, r, http.StatusInternalServerError, "Failed to get latest price", err)
 		return
 	}
+	api.rememberLatest(feed.Name, response)
 
 	// Cache and save to DB
 	cacheData := &cache.RoundData{
@@ -127,8 +376,9 @@ func (api *API) GetLatestPriceHandler(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:       response.UpdatedAt,
 		AnsweredInRound: response.AnsweredInRound,
 	}
-	api.cache.Set(ctx, "latest", cacheData, 10*time.Second)
+	api.cache.Set(ctx, cacheKey, cacheData, api.settings.Load().LatestCacheTTL)
 	api.db.Save(ctx, &db.OracleRound{
+		Feed:            feed.Name,
 		RoundID:         response.RoundID,
 		Answer:          response.Answer,
 		StartedAt:       time.Unix(response.StartedAt, 0),
@@ -136,30 +386,43 @@ func (api *API) GetLatestPriceHandler(w http.ResponseWriter, r *http.Request) {
 		AnsweredInRound: response.AnsweredInRound,
 	})
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	metrics.ResponseSources.WithLabelValues("latestPrice", "rpc").Inc()
+	writeLatest(w, feed, response, strict)
 }
 
-// GetRoundDataHandler handles GET /round/{id}
+// GetRoundDataHandler handles GET /round/{id} and GET /feeds/{name}/round/{id}
 func (api *API) GetRoundDataHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	roundIdStr := r.URL.Path[len("/round/"):]
-	roundId, err := strconv.ParseUint(roundIdStr, 10, 64)
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	roundId, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
 	if err != nil {
 		http.Error(w, "Invalid round ID", http.StatusBadRequest)
 		return
 	}
+	cacheKey := roundCacheKey(feed.Name, roundId)
 
 	// Try cache first
-	if data, err := api.cache.Get(ctx, "round:"+roundIdStr); err == nil && data != nil {
+	if data, err := api.cache.Get(ctx, cacheKey); err == nil && data != nil {
+		response := RoundData{
+			RoundID:         data.RoundID,
+			Answer:          data.Answer,
+			StartedAt:       data.StartedAt,
+			UpdatedAt:       data.UpdatedAt,
+			AnsweredInRound: data.AnsweredInRound,
+		}
+		metrics.ResponseSources.WithLabelValues("round", "cache").Inc()
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(data)
+		json.NewEncoder(w).Encode(withPrice(feed, response))
 		return
 	}
 
 	// Try database
-	if dbData, err := api.db.GetByRoundID(ctx, roundId); err == nil && dbData != nil {
+	if dbData, err := api.db.GetByRoundID(ctx, feed.Name, roundId); err == nil && dbData != nil {
 		response := RoundData{
 			RoundID:         dbData.RoundID,
 			Answer:          dbData.Answer,
@@ -174,17 +437,18 @@ func (api *API) GetRoundDataHandler(w http.ResponseWriter, r *http.Request) {
 			UpdatedAt:       response.UpdatedAt,
 			AnsweredInRound: response.AnsweredInRound,
 		}
-		api.cache.Set(ctx, "round:"+roundIdStr, cacheData, 10*time.Second)
+		api.cache.Set(ctx, cacheKey, cacheData, api.settings.Load().RoundCacheTTL)
+		metrics.ResponseSources.WithLabelValues("round", "db").Inc()
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		json.NewEncoder(w).Encode(withPrice(feed, response))
 		return
 	}
 
 	// Fallback to RPC with retry
 	var response RoundData
-	err = retry.Retry(ctx, func() error {
+	err = api.readRetry.Do(ctx, func(ctx context.Context) error {
 		roundIdBig := big.NewInt(int64(roundId))
-		_, answer, startedAt, updatedAt, answeredInRound, err := api.reader.GetRoundData(ctx, roundIdBig)
+		_, answer, startedAt, updatedAt, answeredInRound, err := feed.Reader.GetRoundData(ctx, roundIdBig)
 		if err != nil {
 			return err
 		}
@@ -199,7 +463,7 @@ func (api *API) GetRoundDataHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get round data: %v", err), http.StatusInternalServerError)
+		serverError(w, r, http.StatusInternalServerError, "Failed to get round data", err)
 		return
 	}
 
@@ -211,8 +475,9 @@ func (api *API) GetRoundDataHandler(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:       response.UpdatedAt,
 		AnsweredInRound: response.AnsweredInRound,
 	}
-	api.cache.Set(ctx, "round:"+roundIdStr, cacheData, 10*time.Second)
+	api.cache.Set(ctx, cacheKey, cacheData, api.settings.Load().RoundCacheTTL)
 	api.db.Save(ctx, &db.OracleRound{
+		Feed:            feed.Name,
 		RoundID:         response.RoundID,
 		Answer:          response.Answer,
 		StartedAt:       time.Unix(response.StartedAt, 0),
@@ -220,14 +485,25 @@ func (api *API) GetRoundDataHandler(w http.ResponseWriter, r *http.Request) {
 		AnsweredInRound: response.AnsweredInRound,
 	})
 
+	metrics.ResponseSources.WithLabelValues("round", "rpc").Inc()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(withPrice(feed, response))
 }
 
-// UpdatePriceHandler handles POST /updatePrice
+// UpdatePriceHandler handles POST /updatePrice and POST /feeds/{name}/updatePrice
 func (api *API) UpdatePriceHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	if feed.Updater == nil {
+		http.Error(w, fmt.Sprintf("Feed %s is read-only", feed.Name), http.StatusForbidden)
+		return
+	}
+
 	var req UpdatePriceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -246,9 +522,9 @@ func (api *API) UpdatePriceHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check ownership
-	isOwner, err := api.updater.IsOwner(ctx)
+	isOwner, err := feed.Updater.IsOwner(ctx)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to check ownership: %v", err), http.StatusInternalServerError)
+		serverError(w, r, http.StatusInternalServerError, "Failed to check ownership", err)
 		return
 	}
 
@@ -259,64 +535,228 @@ func (api *API) UpdatePriceHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Update price with retry
 	var txHash common.Hash
-	err = retry.Retry(ctx, func() error {
+	err = api.txRetry.Do(ctx, func(ctx context.Context) error {
 		var err error
-		txHash, err = api.updater.UpdatePrice(ctx, newAnswer)
+		txHash, err = feed.Updater.UpdatePrice(ctx, newAnswer)
 		return err
 	})
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update price: %v", err), http.StatusInternalServerError)
+		serverError(w, r, http.StatusInternalServerError, "Failed to update price", err)
+		return
+	}
+
+	// Wait for the transaction to reach the configured confirmation depth
+	waitCtx, cancel := context.WithTimeout(ctx, api.txWaitTimeout)
+	defer cancel()
+
+	txRecord, receipt, err := feed.Updater.WaitForTransaction(waitCtx, txHash)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		serverError(w, r, http.StatusInternalServerError, "Failed to track transaction", err)
+		return
+	}
+
+	// Not final yet: the client can poll /tx/{hash}
+	if txRecord == nil || !txRecord.IsFinal() {
+		response := UpdatePriceResponse{TxHash: txHash.Hex(), Status: db.TxPending}
+		if txRecord != nil {
+			response.TxHash = txRecord.TxHash
+			response.Status = txRecord.Status
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if txRecord.Status != db.TxConfirmed {
+		slog.WarnContext(ctx, "update transaction not confirmed", "feed", feed.Name, "tx", txRecord.TxHash, "status", txRecord.Status, "err", txRecord.Error)
+		http.Error(w, fmt.Sprintf("Transaction %s %s: %s", txRecord.TxHash, txRecord.Status, txRecord.Error), http.StatusBadGateway)
+		return
+	}
+
+	// A fee bump may have replaced the original transaction
+	txHash = common.HexToHash(txRecord.TxHash)
+
+	event, err := feed.Updater.ParseAnswerUpdated(receipt)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to read update event", err)
 		return
 	}
 
-	// Get updated data with retry
+	// Get the round created by this transaction with retry
 	var roundId, answer, startedAt, updatedAt, answeredInRound *big.Int
-	err = retry.Retry(ctx, func() error {
+	err = api.readRetry.Do(ctx, func(ctx context.Context) error {
 		var err error
-		roundId, answer, startedAt, updatedAt, answeredInRound, err = api.reader.GetLatestRoundData(ctx)
+		roundId, answer, startedAt, updatedAt, answeredInRound, err = feed.Reader.GetRoundData(ctx, event.RoundId)
 		return err
 	})
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get updated data: %v", err), http.StatusInternalServerError)
+		serverError(w, r, http.StatusInternalServerError, "Failed to get updated data", err)
 		return
 	}
 
 	response := UpdatePriceResponse{
 		TxHash:    txHash.Hex(),
+		Status:    txRecord.Status,
 		RoundID:   roundId.Uint64(),
 		Answer:    answer.String(),
+		Price:     feedPrice(feed, answer.String()),
 		UpdatedAt: updatedAt.Int64(),
 	}
 
 	// Invalidate cache and save to DB
-	api.cache.Del(ctx, "latest")
+	api.cache.Del(ctx, latestCacheKey(feed.Name))
 	api.db.Save(ctx, &db.OracleRound{
+		Feed:            feed.Name,
 		RoundID:         roundId.Uint64(),
 		Answer:          answer.String(),
 		StartedAt:       time.Unix(startedAt.Int64(), 0),
 		UpdatedAt:       time.Unix(updatedAt.Int64(), 0),
 		AnsweredInRound: answeredInRound.Uint64(),
 		TxHash:          txHash.Hex(),
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		LogIndex:        event.Raw.Index,
 	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetTransactionHandler handles GET /tx/{hash}
+func (api *API) GetTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	hash := r.PathValue("hash")
+	if !isTxHash(hash) {
+		http.Error(w, "Invalid transaction hash", http.StatusBadRequest)
+		return
+	}
+
+	txRecord, err := api.db.GetTransaction(ctx, common.HexToHash(hash).Hex())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get transaction", err)
+		return
+	}
+	if txRecord == nil {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+
+	response := TransactionResponse{
+		TxHash:        txRecord.TxHash,
+		Feed:          txRecord.Feed,
+		Chain:         txRecord.Chain,
+		From:          txRecord.FromAddress,
+		Nonce:         txRecord.Nonce,
+		Answer:        txRecord.Answer,
+		GasLimit:      txRecord.GasLimit,
+		GasFeeCap:     txRecord.GasFeeCap,
+		GasTipCap:     txRecord.GasTipCap,
+		Replaces:      txRecord.Replaces,
+		ReplacedBy:    txRecord.ReplacedBy,
+		Status:        txRecord.Status,
+		BlockNumber:   txRecord.BlockNumber,
+		BlockHash:     txRecord.BlockHash,
+		Confirmations: txRecord.Confirmations,
+		GasUsed:       txRecord.GasUsed,
+		Error:         txRecord.Error,
+		CreatedAt:     txRecord.CreatedAt.Unix(),
+		UpdatedAt:     txRecord.UpdatedAt.Unix(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// isTxHash reports whether s is a 0x-prefixed 32-byte hex string
+func isTxHash(s string) bool {
+	if len(s) != 66 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
 // HealthHandler handles GET /health
 func (api *API) HealthHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	response := api.checkHealth(r.Context())
 
-	_, rpcErr := api.reader.GetLatestPrice(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ProvidersHandler handles GET /providers
+func (api *API) ProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string][]rpc.ProviderStatus)
+	for chain, pool := range api.pools() {
+		response[chain] = pool.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// pools returns the RPC provider pool of every chain that serves a feed
+func (api *API) pools() map[string]*rpc.Pool {
+	pools := make(map[string]*rpc.Pool)
+	for _, feed := range api.feeds.All() {
+		if feed.RPC != nil {
+			pools[feed.Chain] = feed.RPC
+		}
+	}
+	return pools
+}
+
+// checkHealth probes the cache, the database and every feed's RPC endpoint,
+// and checks that each feed's latest round is fresh and complete
+func (api *API) checkHealth(ctx context.Context) HealthResponse {
 	response := HealthResponse{
 		Status:            "ok",
-		RPCConnected:      rpcErr == nil,
+		RPCConnected:      true,
 		RedisConnected:    api.cache.Ping(ctx) == nil,
 		PostgresConnected: api.db.Ping(ctx) == nil,
+		Feeds:             make(map[string]bool),
+		Providers:         make(map[string][]ProviderHealth),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	for chain, pool := range api.pools() {
+		for _, status := range pool.Status() {
+			response.Providers[chain] = append(response.Providers[chain], ProviderHealth{
+				Name:    status.Name,
+				Healthy: status.Healthy,
+				Breaker: status.Breaker.State,
+			})
+		}
+	}
+
+	for _, feed := range api.feeds.All() {
+		roundId, _, _, updatedAt, answeredInRound, rpcErr := feed.Reader.GetLatestRoundData(ctx)
+		response.Feeds[feed.Name] = rpcErr == nil
+		if rpcErr != nil {
+			response.RPCConnected = false
+			continue
+		}
+
+		latest := latestRound(feed, RoundData{RoundID: roundId.Uint64(), UpdatedAt: updatedAt.Int64()}, time.Now())
+		metrics.FeedRoundAge.WithLabelValues(feed.Name).Set(float64(latest.AgeSeconds))
+
+		var reasons []string
+		if latest.Stale {
+			reasons = append(reasons, "stale")
+		}
+		if answeredInRound.Cmp(roundId) < 0 {
+			reasons = append(reasons, "incomplete_round")
+		}
+		if len(reasons) > 0 {
+			if response.DegradedFeeds == nil {
+				response.DegradedFeeds = make(map[string][]string)
+			}
+			response.DegradedFeeds[feed.Name] = reasons
+			response.Status = "degraded"
+		}
+	}
+
+	return response
 }
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/feeds"
+	"github.com/114windd/oracle-client/internal/history"
+)
+
+// historyWindow is what is known of a feed's answers during a time range
+type historyWindow struct {
+	prev   *history.Point
+	points []history.Point
+	gaps   []history.Gap
+}
+
+// loadWindow reads the rounds of feed updated in [start, end) and the last
+// one before start. The indexer may be behind or disabled, so rounds missing
+// from the database are read from chain, up to maxGapFill, and stored; the
+// spans of those still missing are returned as gaps.
+func (api *API) loadWindow(ctx context.Context, feed *feeds.Feed, start, end time.Time) (historyWindow, error) {
+	rounds, err := api.db.GetRoundsBetween(ctx, feed.Name, start, end)
+	if err != nil {
+		return historyWindow{}, err
+	}
+	before, err := api.db.GetRoundBefore(ctx, feed.Name, start)
+	if err != nil {
+		return historyWindow{}, err
+	}
+
+	// The first round after the window bounds the IDs that may fall in it
+	next, ok, err := api.db.FirstRoundSince(ctx, feed.Name, end)
+	if err != nil {
+		return historyWindow{}, err
+	}
+	if !ok {
+		next, err = api.nextRoundId(ctx, feed)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to get latest round, treating stored rounds as complete", "feed", feed.Name, "err", err)
+			next = 0
+			if before != nil {
+				next = before.RoundID + 1
+			}
+			if len(rounds) > 0 {
+				next = rounds[len(rounds)-1].RoundID + 1
+			}
+		}
+	}
+
+	// Without a round before the window, the rounds before the first stored
+	// one are not looked for: no price is known there either way
+	lo := next
+	if before != nil {
+		lo = before.RoundID
+	} else if len(rounds) > 0 {
+		lo = rounds[0].RoundID
+	}
+
+	known := make(map[uint64]bool, len(rounds))
+	for _, round := range rounds {
+		known[round.RoundID] = true
+	}
+	var missing []uint64
+	for id := lo + 1; id < next && len(missing) < maxGapFill; id++ {
+		if !known[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	filled, err := api.fetchRounds(ctx, feed, missing)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to fill missing rounds from chain", "feed", feed.Name, "rounds", len(missing), "err", err)
+	} else if len(filled) > 0 {
+		slog.DebugContext(ctx, "filled missing rounds from chain", "feed", feed.Name, "rounds", len(filled))
+		if err := api.db.SaveRounds(ctx, filled); err != nil {
+			slog.WarnContext(ctx, "failed to store rounds read from chain", "feed", feed.Name, "err", err)
+		}
+	}
+	for _, round := range filled {
+		switch {
+		case round.UpdatedAt.Before(start):
+			if before == nil || round.RoundID > before.RoundID {
+				before = round
+			}
+		case round.UpdatedAt.Before(end):
+			rounds = append(rounds, round)
+		default:
+			next = min(next, round.RoundID)
+		}
+	}
+	slices.SortFunc(rounds, func(a, b *db.OracleRound) int {
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.RoundID, b.RoundID)
+	})
+
+	var window historyWindow
+	if before != nil {
+		p, err := history.NewPoint(before.RoundID, before.UpdatedAt, before.Answer)
+		if err != nil {
+			return historyWindow{}, err
+		}
+		window.prev = &p
+	}
+	if window.points, err = historyPoints(rounds); err != nil {
+		return historyWindow{}, err
+	}
+	window.gaps = history.FindGaps(window.prev, window.points, next, start, end)
+	return window, nil
+}
+
+// nextRoundId returns the ID the chain's next round will have
+func (api *API) nextRoundId(ctx context.Context, feed *feeds.Feed) (uint64, error) {
+	var latest *big.Int
+	err := api.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		latest, err = feed.Reader.GetLatestRoundId(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return latest.Uint64() + 1, nil
+}
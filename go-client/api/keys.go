@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/apikeys"
+	"github.com/114windd/oracle-client/internal/db"
+)
+
+// API key statuses
+const (
+	KeyActive  = "active"
+	KeyExpired = "expired"
+	KeyRevoked = "revoked"
+)
+
+// CreateKeyRequest represents a request to create an API key
+type CreateKeyRequest struct {
+	Label     string   `json:"label"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt int64    `json:"expiresAt,omitempty"` // unix seconds; 0 never expires
+}
+
+// KeyInfo describes an API key without its secret
+type KeyInfo struct {
+	ID        string   `json:"id"`
+	Label     string   `json:"label"`
+	Scopes    []string `json:"scopes"`
+	Status    string   `json:"status"`
+	ExpiresAt int64    `json:"expiresAt,omitempty"`
+	RevokedAt int64    `json:"revokedAt,omitempty"`
+	CreatedBy string   `json:"createdBy,omitempty"`
+	RevokedBy string   `json:"revokedBy,omitempty"`
+	CreatedAt int64    `json:"createdAt"`
+}
+
+// CreateKeyResponse carries a new key. The token is not stored and cannot be
+// retrieved again.
+type CreateKeyResponse struct {
+	KeyInfo
+	Key string `json:"key"`
+}
+
+// CreateKeyHandler handles POST /admin/keys
+func (api *API) CreateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Label = strings.TrimSpace(req.Label)
+	if req.Label == "" {
+		http.Error(w, "label is required", http.StatusBadRequest)
+		return
+	}
+
+	scopes, err := apikeys.ParseScopes(req.Scopes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != 0 {
+		t := time.Unix(req.ExpiresAt, 0)
+		if !t.After(time.Now()) {
+			http.Error(w, "expiresAt must be in the future", http.StatusBadRequest)
+			return
+		}
+		expiresAt = &t
+	}
+
+	id, token, secretHash, err := apikeys.Generate()
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to generate API key", err)
+		return
+	}
+
+	principal, _ := PrincipalFrom(ctx)
+	key := &db.APIKey{
+		ID:         id,
+		Label:      req.Label,
+		Scopes:     strings.Join(scopes, ","),
+		SecretHash: secretHash,
+		ExpiresAt:  expiresAt,
+		CreatedBy:  principal.KeyID,
+	}
+	if err := api.db.CreateAPIKey(ctx, key); err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to save API key", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "api key created", "key", id, "label", key.Label, "scopes", key.Scopes)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateKeyResponse{KeyInfo: keyInfo(key), Key: token})
+}
+
+// ListKeysHandler handles GET /admin/keys
+func (api *API) ListKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := api.db.ListAPIKeys(r.Context())
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to list API keys", err)
+		return
+	}
+
+	response := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, keyInfo(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RevokeKeyHandler handles DELETE /admin/keys/{id}
+func (api *API) RevokeKeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+
+	principal, _ := PrincipalFrom(ctx)
+	revoked, err := api.db.RevokeAPIKey(ctx, id, principal.KeyID)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to revoke API key", err)
+		return
+	}
+	if !revoked {
+		http.Error(w, "API key not found or already revoked", http.StatusNotFound)
+		return
+	}
+
+	slog.InfoContext(ctx, "api key revoked", "key", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keyInfo describes key as of now
+func keyInfo(key *db.APIKey) KeyInfo {
+	info := KeyInfo{
+		ID:        key.ID,
+		Label:     key.Label,
+		Scopes:    strings.Split(key.Scopes, ","),
+		Status:    KeyActive,
+		CreatedBy: key.CreatedBy,
+		RevokedBy: key.RevokedBy,
+		CreatedAt: key.CreatedAt.Unix(),
+	}
+	if key.ExpiresAt != nil {
+		info.ExpiresAt = key.ExpiresAt.Unix()
+		if !time.Now().Before(*key.ExpiresAt) {
+			info.Status = KeyExpired
+		}
+	}
+	if key.RevokedAt != nil {
+		info.RevokedAt = key.RevokedAt.Unix()
+		info.Status = KeyRevoked
+	}
+	return info
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/114windd/oracle-client/internal/feeds"
+	"github.com/114windd/oracle-client/internal/reader"
+)
+
+// MetadataResponse describes a feed's oracle contract
+type MetadataResponse struct {
+	Feed          string `json:"feed"`
+	Address       string `json:"address"`
+	Chain         string `json:"chain"`
+	Decimals      uint8  `json:"decimals"`
+	Description   string `json:"description"`
+	Version       string `json:"version"`
+	Owner         string `json:"owner"`
+	LatestRoundID uint64 `json:"latestRoundId"`
+}
+
+// MetadataHandler handles GET /metadata and GET /feeds/{name}/metadata.
+// Decimals, description and version are read once per process; owner and
+// latestRoundId are read on every request.
+func (api *API) MetadataHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	var metadata reader.Metadata
+	err := api.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		metadata, err = feed.Reader.GetMetadata(ctx)
+		return err
+	})
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get metadata", err)
+		return
+	}
+
+	response := MetadataResponse{
+		Feed:        feed.Name,
+		Address:     feed.Address.Hex(),
+		Chain:       feed.Chain,
+		Decimals:    metadata.Decimals,
+		Description: metadata.Description,
+		Version:     metadata.Version.String(),
+	}
+
+	err = api.readRetry.Do(ctx, func(ctx context.Context) error {
+		owner, err := feed.Reader.GetOwner(ctx)
+		if err != nil {
+			return err
+		}
+		latest, err := feed.Reader.GetLatestRoundId(ctx)
+		if err != nil {
+			return err
+		}
+		response.Owner = owner.Hex()
+		response.LatestRoundID = latest.Uint64()
+		return nil
+	})
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get metadata", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// withPrice sets the decimal price of a round of feed
+func withPrice(feed *feeds.Feed, data RoundData) RoundData {
+	data.Price = feedPrice(feed, data.Answer)
+	return data
+}
+
+// feedPrice scales a raw answer of feed by its decimals. Responses served
+// from the cache or the database must not wait on the node, so it returns ""
+// until the decimals were read.
+func feedPrice(feed *feeds.Feed, answer string) string {
+	metadata, ok := feed.Reader.CachedMetadata()
+	if !ok {
+		return ""
+	}
+	price, _ := formatPrice(answer, metadata.Decimals)
+	return price
+}
+
+// formatPrice scales a raw answer down by decimals. The digits are moved
+// around the decimal point as text, so no precision is lost; all decimals
+// are kept, e.g. "200012345678" with 8 decimals is "2000.12345678".
+func formatPrice(answer string, decimals uint8) (string, bool) {
+	value, ok := new(big.Int).SetString(answer, 10)
+	if !ok {
+		return "", false
+	}
+
+	digits := new(big.Int).Abs(value).String()
+	if decimals == 0 {
+		return value.String(), true
+	}
+	if pad := int(decimals) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(decimals)
+	price := digits[:point] + "." + digits[point:]
+	if value.Sign() < 0 {
+		price = "-" + price
+	}
+	return price, true
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/114windd/oracle-client/internal/feeds"
+	"github.com/114windd/oracle-client/internal/reader"
+)
+
+func TestFormatPrice(t *testing.T) {
+	tests := []struct {
+		answer   string
+		decimals uint8
+		want     string
+		wantOK   bool
+	}{
+		{"200012345678", 8, "2000.12345678", true},
+		{"200000000000", 8, "2000.00000000", true},
+		{"5", 8, "0.00000005", true},
+		{"12345678", 8, "0.12345678", true},
+		{"-5", 2, "-0.05", true},
+		{"-123456", 2, "-1234.56", true},
+		{"0", 3, "0.000", true},
+		{"42", 0, "42", true},
+		{"-42", 0, "-42", true},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", 18,
+			"115792089237316195423570985008687907853269984665640564039457.584007913129639935", true},
+		{"", 8, "", false},
+		{"1.5", 8, "", false},
+		{"x", 8, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := formatPrice(tt.answer, tt.decimals)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("formatPrice(%q, %d) = %q, %v, want %q, %v", tt.answer, tt.decimals, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFeedPriceWithoutMetadata(t *testing.T) {
+	// Decimals that were never read leave the price out rather than
+	// calling the node
+	feed := &feeds.Feed{Name: "eth-usd", Reader: &reader.Reader{}}
+	if price := feedPrice(feed, "200012345678"); price != "" {
+		t.Errorf("feedPrice = %q, want empty", price)
+	}
+}
@@ -1,12 +1,92 @@
 package api
 
 import (
-	"log"
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/114windd/oracle-client/internal/apikeys"
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/logging"
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/ratelimit"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware stores the client's X-Request-ID, or a generated one, in
+// the request context and echoes it in the response
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// isValidRequestID accepts up to 128 printable ASCII characters, so client
+// IDs cannot inject into log lines or headers
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// TracingMiddleware starts a server span for each request, continuing the
+// trace of an incoming W3C traceparent header. The span is renamed after the
+// route pattern once the ServeMux has matched it, so nothing between this
+// middleware and the mux may copy the request.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.StartServer(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("request.id", logging.RequestID(ctx)),
+		)
+		defer span.End()
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(wrapped, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Method + " " + r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+	})
+}
+
 // LoggingMiddleware logs HTTP requests
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -17,16 +97,65 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrapped, r)
 
-		log.Printf("%s %s %d %v", r.Method, r.URL.Path, wrapped.statusCode, time.Since(start))
+		slog.InfoContext(r.Context(), "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", wrapped.statusCode,
+			"duration", time.Since(start),
+		)
 	})
 }
 
-// AuthMiddleware provides basic authentication for protected endpoints
-func AuthMiddleware(apiKey string) func(http.Handler) http.Handler {
+// MetricsMiddleware records request latency by route pattern and status. It
+// must wrap the ServeMux without copying the request, so that the pattern the
+// mux matched is visible afterwards.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r)
+
+		// Requests rejected before routing, or not matching any route, share
+		// one label so arbitrary paths cannot grow the series count
+		metrics.HTTPRequestDuration.WithLabelValues(routeLabel(r.Pattern), r.Method, strconv.Itoa(wrapped.statusCode)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// StaticKeyID identifies requests authenticated with the API_KEY setting
+const StaticKeyID = "static"
+
+type principalKey struct{}
+
+// Principal is the API key that authenticated a request
+type Principal struct {
+	KeyID  string
+	Scopes []string
+}
+
+// PrincipalFrom returns the principal AuthMiddleware stored in ctx
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// publicPaths are served without an API key: health checks, and metrics for
+// Prometheus scrapers
+var publicPaths = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// AuthMiddleware authenticates requests with a bearer API key. Keys are
+// looked up in keys by ID and their secret compared by hash. A non-empty
+// staticKey is also accepted, with every scope, so that the first managed
+// keys can be created. The key ID is added to the request context, its logs
+// and its span.
+func AuthMiddleware(keys *db.DB, staticKey string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip auth for health endpoint
-			if r.URL.Path == "/health" {
+			if publicPaths[r.URL.Path] {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -45,63 +174,223 @@ func AuthMiddleware(apiKey string) func(http.Handler) http.Handler {
 			}
 
 			token := strings.TrimPrefix(authHeader, "Bearer ")
-			if token != apiKey {
+			principal, err := authenticate(r.Context(), keys, staticKey, token)
+			if err != nil {
+				serverError(w, r, http.StatusInternalServerError, "Failed to check API key", err)
+				return
+			}
+			if principal == nil {
 				http.Error(w, "Invalid API key", http.StatusUnauthorized)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("api_key.id", principal.KeyID))
+			ctx := context.WithValue(r.Context(), principalKey{}, *principal)
+			ctx = logging.WithAPIKeyID(ctx, principal.KeyID)
+			authed := r.WithContext(ctx)
+			next.ServeHTTP(w, authed)
+
+			// The mux sets the matched pattern on the copy; hand it back to
+			// TracingMiddleware and MetricsMiddleware
+			r.Pattern = authed.Pattern
 		})
 	}
 }
 
-// RateLimitMiddleware provides basic rate limiting
-func RateLimitMiddleware(next http.Handler) http.Handler {
-	// Simple in-memory rate limiter (in production, use Redis or similar)
-	requests := make(map[string][]time.Time)
+// authenticate returns the principal for token, or nil if the key is
+// unknown, revoked, expired or its secret does not match
+func authenticate(ctx context.Context, keys *db.DB, staticKey, token string) (*Principal, error) {
+	if staticKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(staticKey)) == 1 {
+		return &Principal{KeyID: StaticKeyID, Scopes: []string{apikeys.ScopeAdmin}}, nil
+	}
+
+	id, secret, ok := apikeys.Parse(token)
+	if !ok {
+		return nil, nil
+	}
+	key, err := keys.GetAPIKey(ctx, id)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt)) {
+		return nil, nil
+	}
+	if !apikeys.Verify(secret, key.SecretHash) {
+		return nil, nil
+	}
+	return &Principal{KeyID: key.ID, Scopes: strings.Split(key.Scopes, ",")}, nil
+}
 
+// RequireScope only lets requests whose API key has scope reach next.
+// Requests that skipped authentication, such as /health, are rejected too.
+func RequireScope(scope string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		clientIP := getClientIP(r)
-		now := time.Now()
-
-		// Clean old requests (older than 1 minute)
-		if clientRequests, exists := requests[clientIP]; exists {
-			var validRequests []time.Time
-			for _, reqTime := range clientRequests {
-				if now.Sub(reqTime) < time.Minute {
-					validRequests = append(validRequests, reqTime)
-				}
+		principal, ok := PrincipalFrom(r.Context())
+		if !ok || !apikeys.HasScope(principal.Scopes, scope) {
+			http.Error(w, fmt.Sprintf("API key lacks the %s scope", scope), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimitMiddleware takes a token from the client's bucket, and from its
+// bucket for the route if the route has a limit of its own, using the current
+// RateLimits. Clients are identified by the API key AuthMiddleware found, or
+// else by IP, so it must run inside AuthMiddleware. mux is asked which route
+// a request will match. If the limiter fails, requests are let through.
+// publicPaths are exempt, so health checks and scrapers sharing an IP are
+// never throttled; only IPRateLimitMiddleware applies to them.
+func RateLimitMiddleware(limiter ratelimit.Limiter, settings *Settings, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			policy := settings.Load().RateLimits
+			_, route := mux.Handler(r)
+
+			client := "ip:" + clientIP(r, settings.Load().TrustedProxies)
+			var scopes []string
+			if principal, ok := PrincipalFrom(ctx); ok {
+				client = "key:" + principal.KeyID
+				scopes = principal.Scopes
 			}
-			requests[clientIP] = validRequests
+
+			buckets := []bucket{{"client", client, policy.ForScopes(scopes)}}
+			if limit, ok := policy.Routes[route]; ok {
+				buckets = append(buckets, bucket{"route", "route:" + route + ":" + client, limit})
+			}
+
+			if allow(w, r, limiter, route, buckets) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// IPRateLimitMiddleware takes a token from the bucket of the client's IP
+// using the current RateLimits.IP, before the request is authenticated, so
+// requests with invalid API keys are throttled as well. It must run outside
+// AuthMiddleware.
+func IPRateLimitMiddleware(limiter ratelimit.Limiter, settings *Settings, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := settings.Load()
+			limit := current.RateLimits.IP
+			if limit.Requests == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			_, route := mux.Handler(r)
+			if allow(w, r, limiter, route, []bucket{{"ip", "preauth:ip:" + clientIP(r, current.TrustedProxies), limit}}) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// bucket is a token bucket a request takes from; name labels rejections
+type bucket struct {
+	name, key string
+	limit     ratelimit.Limit
+}
+
+// allow takes a token from each bucket and sets the rate limit headers. It
+// answers 429 and returns false once one is empty.
+func allow(w http.ResponseWriter, r *http.Request, limiter ratelimit.Limiter, route string, buckets []bucket) bool {
+	ctx := r.Context()
+
+	// Report the bucket closest to running out, or the one that did
+	var reported *ratelimit.Result
+	for _, b := range buckets {
+		result, err := limiter.Allow(ctx, b.key, b.limit)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limiter unavailable, allowing request", "err", err)
+			continue
+		}
+		if reported == nil || !result.Allowed || result.Remaining < reported.Remaining {
+			reported = &result
 		}
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(routeLabel(route), b.name).Inc()
+			break
+		}
+	}
 
-		// Check rate limit (10 requests per minute)
-		if len(requests[clientIP]) >= 10 {
+	if reported != nil {
+		setRateLimitHeaders(w.Header(), *reported)
+		if !reported.Allowed {
 			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-			return
+			return false
 		}
+	}
+	return true
+}
 
-		// Add current request
-		requests[clientIP] = append(requests[clientIP], now)
+// setRateLimitHeaders sets the RateLimit header fields of the IETF
+// httpapi-ratelimit-headers draft, and Retry-After on rejections
+func setRateLimitHeaders(h http.Header, result ratelimit.Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit.Requests, ceilSeconds(result.Limit.Window)))
+	if !result.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+	}
+}
 
-		next.ServeHTTP(w, r)
-	})
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
 }
 
-// CORSMiddleware adds CORS headers
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// routeLabel is the metrics label of a route pattern; "" means the request
+// matched no route
+func routeLabel(route string) string {
+	if route == "" {
+		return "unmatched"
+	}
+	return route
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// CORSMiddleware adds CORS headers for the current CORSOrigins
+func CORSMiddleware(settings *Settings) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if origin := allowedOrigin(settings.Load().CORSOrigins, r.Header.Get("Origin")); origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, traceparent, tracestate, "+RequestIDHeader)
+				w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
+			}
+			w.Header().Add("Vary", "Origin")
 
-		next.ServeHTTP(w, r)
-	})
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request
+// from origin, or "" if it is not allowed
+func allowedOrigin(allowed []string, origin string) string {
+	for _, candidate := range allowed {
+		if candidate == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(strings.TrimSuffix(candidate, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code
@@ -115,23 +404,60 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// getClientIP extracts the client IP from the request
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return strings.Split(xff, ",")[0]
-	}
-
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// Fall back to RemoteAddr
-	return r.RemoteAddr
+// Hijack lets WebSocket upgrades take over the connection
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
 
+// clientIP returns the IP address of the client that sent r. The port is
+// left out, so that every connection from one host counts as the same
+// client. X-Forwarded-For and X-Real-IP are only believed when the request
+// comes from one of the trusted proxies: X-Forwarded-For is read from the
+// right, skipping trusted proxies, so a client cannot choose its address by
+// sending the header itself.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(addr, trusted) {
+		return host
+	}
 
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			addr = hop
+			if !isTrusted(hop, trusted) {
+				break
+			}
+		}
+		return addr.String()
+	}
 
+	if xri, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return xri.String()
+	}
+	return addr.String()
+}
 
+// isTrusted reports whether addr belongs to one of the trusted proxies
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
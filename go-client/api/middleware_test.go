@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/ratelimit"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"port is dropped", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"ipv6", "[2001:db8::1]:443", nil, "2001:db8::1"},
+		{"untrusted forwarded for is ignored", "203.0.113.7:1", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.7"},
+		{"untrusted real ip is ignored", "203.0.113.7:1", map[string]string{"X-Real-IP": "1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy forwards the client", "10.0.0.2:1", map[string]string{"X-Forwarded-For": "198.51.100.9"}, "198.51.100.9"},
+		{"spoofed hops left of the client are ignored", "10.0.0.2:1", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 10.0.0.3"}, "198.51.100.9"},
+		{"trusted proxy real ip", "10.0.0.2:1", map[string]string{"X-Real-IP": "198.51.100.9"}, "198.51.100.9"},
+		{"trusted proxy without headers", "10.0.0.2:1", nil, "10.0.0.2"},
+		{"malformed hop stops the walk", "10.0.0.2:1", map[string]string{"X-Forwarded-For": "junk, 10.0.0.3"}, "10.0.0.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/latestPrice", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			if got := clientIP(r, trusted); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// limited serves routes through both rate limiters with the memory backend
+func limited(policy ratelimit.Policy) http.Handler {
+	mux := http.NewServeMux()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/latestPrice", ok)
+	mux.Handle("/health", ok)
+
+	limiter := ratelimit.NewMemory()
+	settings := NewSettings(Tunables{RateLimits: policy})
+	return IPRateLimitMiddleware(limiter, settings, mux)(RateLimitMiddleware(limiter, settings, mux)(mux))
+}
+
+func TestRateLimitSharedAcrossConnections(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ratelimit.Policy
+	}{
+		{"client limit", ratelimit.Policy{Default: ratelimit.Limit{Requests: 1, Window: time.Minute}}},
+		{"ip limit", ratelimit.Policy{
+			Default: ratelimit.Limit{Requests: 100, Window: time.Minute},
+			IP:      ratelimit.Limit{Requests: 1, Window: time.Minute},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := limited(tt.policy)
+
+			// Two connections from one host, the second claiming another
+			// address in a header it is not trusted to send
+			first := httptest.NewRequest(http.MethodGet, "/latestPrice", nil)
+			first.RemoteAddr = "203.0.113.7:40000"
+			second := httptest.NewRequest(http.MethodGet, "/latestPrice", nil)
+			second.RemoteAddr = "203.0.113.7:40001"
+			second.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, first)
+			if w.Code != http.StatusOK {
+				t.Fatalf("first request status = %d, want 200", w.Code)
+			}
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, second)
+			if w.Code != http.StatusTooManyRequests {
+				t.Errorf("second connection status = %d, want 429", w.Code)
+			}
+		})
+	}
+}
+
+func TestRateLimitExemptsPublicPaths(t *testing.T) {
+	handler := limited(ratelimit.Policy{Default: ratelimit.Limit{Requests: 1, Window: time.Minute}})
+
+	for i := range 5 {
+		r := httptest.NewRequest(http.MethodGet, "/health", nil)
+		r.RemoteAddr = "203.0.113.7:40000"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("health check %d status = %d, want 200", i, w.Code)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/feeds"
+)
+
+// Page sizes of GET /rounds
+const (
+	defaultRoundsLimit = 100
+	maxRoundsLimit     = 500
+)
+
+// gapFillConcurrency bounds the rounds read from chain at once to fill gaps
+const gapFillConcurrency = 8
+
+// maxGapFill bounds the rounds a single request reads from chain. A page
+// with more missing rounds ends early, and its cursor continues from there.
+const maxGapFill = 50
+
+// RoundsResponse is a page of rounds. NextCursor is set while more rounds
+// match the query.
+type RoundsResponse struct {
+	Rounds     []RoundData `json:"rounds"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// roundsQuery holds the parameters of GET /rounds. Unset bounds are nil.
+type roundsQuery struct {
+	fromRound, toRound *uint64
+	from, to           *time.Time
+	descending         bool
+	limit              uint64
+	cursor             *uint64
+}
+
+// parseRoundsQuery reads fromRound, toRound, from, to (unix seconds, both
+// inclusive), order (asc or desc), limit and cursor
+func parseRoundsQuery(values url.Values) (roundsQuery, error) {
+	q := roundsQuery{descending: true, limit: defaultRoundsLimit}
+
+	var err error
+	if q.fromRound, err = parseUintParam(values, "fromRound"); err != nil {
+		return q, err
+	}
+	if q.toRound, err = parseUintParam(values, "toRound"); err != nil {
+		return q, err
+	}
+	if q.fromRound != nil && q.toRound != nil && *q.fromRound > *q.toRound {
+		return q, fmt.Errorf("fromRound must not be greater than toRound")
+	}
+	if q.from, err = parseTimeParam(values, "from"); err != nil {
+		return q, err
+	}
+	if q.to, err = parseTimeParam(values, "to"); err != nil {
+		return q, err
+	}
+	if q.from != nil && q.to != nil && q.from.After(*q.to) {
+		return q, fmt.Errorf("from must not be after to")
+	}
+
+	switch values.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.descending = false
+	default:
+		return q, fmt.Errorf("order must be asc or desc")
+	}
+
+	if limit, err := parseUintParam(values, "limit"); err != nil {
+		return q, err
+	} else if limit != nil {
+		if *limit == 0 || *limit > maxRoundsLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxRoundsLimit)
+		}
+		q.limit = *limit
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		roundId, err := strconv.ParseUint(string(raw), 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		q.cursor = &roundId
+	}
+	return q, nil
+}
+
+func parseUintParam(values url.Values, name string) (*uint64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &n, nil
+}
+
+func parseTimeParam(values url.Values, name string) (*time.Time, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, want unix seconds", name)
+	}
+	t := time.Unix(seconds, 0)
+	return &t, nil
+}
+
+// encodeCursor makes the cursor that continues a page at roundId
+func encodeCursor(roundId uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(roundId, 10)))
+}
+
+// ListRoundsHandler handles GET /rounds and GET /feeds/{name}/rounds.
+//
+// Round IDs are consecutive on chain and a round's updatedAt never precedes
+// the one before it, so each page covers a window of limit round IDs. The
+// time filters narrow the ID range using the stored rounds around them.
+// Rounds of the window missing from the database are read from chain and
+// stored; the time filters are applied once the window is complete.
+func (api *API) ListRoundsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	q, err := parseRoundsQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lo, hi, fill, err := api.roundRange(ctx, feed, q)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get round range", err)
+		return
+	}
+
+	response := RoundsResponse{Rounds: []RoundData{}}
+
+	// Pick the window of this page and the cursor of the next one
+	var first, last uint64
+	if !q.descending {
+		first = lo
+		if q.cursor != nil {
+			first = max(first, *q.cursor)
+		}
+		last = hi
+		if first <= hi && hi-first >= q.limit {
+			last = first + q.limit - 1
+			response.NextCursor = encodeCursor(last + 1)
+		}
+	} else {
+		last = hi
+		if q.cursor != nil {
+			last = min(last, *q.cursor)
+		}
+		first = lo
+		if lo <= last && last-lo >= q.limit {
+			first = last - q.limit + 1
+			response.NextCursor = encodeCursor(first - 1)
+		}
+	}
+	if first > last {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	stored, err := api.db.GetRoundRange(ctx, feed.Name, first, last)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get rounds", err)
+		return
+	}
+
+	rounds := make(map[uint64]*db.OracleRound, len(stored))
+	for _, round := range stored {
+		rounds[round.RoundID] = round
+	}
+
+	if fill {
+		var missing []uint64
+		for id := first; id <= last; id++ {
+			if rounds[id] == nil {
+				missing = append(missing, id)
+			}
+		}
+
+		// Cut the page short before the first round it can't fill
+		if len(missing) > maxGapFill {
+			if !q.descending {
+				cut := missing[maxGapFill]
+				last = cut - 1
+				response.NextCursor = encodeCursor(cut)
+				missing = missing[:maxGapFill]
+			} else {
+				cut := missing[len(missing)-maxGapFill-1]
+				first = cut + 1
+				response.NextCursor = encodeCursor(cut)
+				missing = missing[len(missing)-maxGapFill:]
+			}
+		}
+
+		filled, err := api.fetchRounds(ctx, feed, missing)
+		if err != nil {
+			serverError(w, r, http.StatusInternalServerError, "Failed to fill missing rounds from chain", err)
+			return
+		}
+		if len(filled) > 0 {
+			slog.DebugContext(ctx, "filled missing rounds from chain", "feed", feed.Name, "rounds", len(filled))
+			if err := api.db.SaveRounds(ctx, filled); err != nil {
+				slog.WarnContext(ctx, "failed to store rounds read from chain", "feed", feed.Name, "err", err)
+			}
+		}
+		for _, round := range filled {
+			rounds[round.RoundID] = round
+		}
+	}
+
+	for i := uint64(0); i <= last-first; i++ {
+		id := first + i
+		if q.descending {
+			id = last - i
+		}
+
+		round := rounds[id]
+		if round == nil {
+			continue
+		}
+		if (q.from != nil && round.UpdatedAt.Before(*q.from)) || (q.to != nil && round.UpdatedAt.After(*q.to)) {
+			continue
+		}
+		response.Rounds = append(response.Rounds, withPrice(feed, roundFromDB(round)))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// roundRange resolves the query's filters to an inclusive range of round
+// IDs. fill reports whether the range was checked against the chain's latest
+// round, so missing rounds within it exist and can be read from chain. An
+// empty range has lo > hi.
+func (api *API) roundRange(ctx context.Context, feed *feeds.Feed, q roundsQuery) (lo, hi uint64, fill bool, err error) {
+	lo = 1
+
+	// The latest round bounds every range; without RPC, the latest stored
+	// round does
+	var latest *big.Int
+	err = api.readRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		latest, err = feed.Reader.GetLatestRoundId(ctx)
+		return err
+	})
+	if err == nil {
+		hi, fill = latest.Uint64(), true
+	} else {
+		slog.WarnContext(ctx, "failed to get latest round, serving stored rounds only", "feed", feed.Name, "err", err)
+		stored, err := api.db.GetLatest(ctx, feed.Name)
+		if err != nil || stored == nil {
+			return 1, 0, false, err
+		}
+		hi = stored.RoundID
+	}
+
+	if q.fromRound != nil {
+		lo = max(lo, *q.fromRound)
+	}
+	if q.toRound != nil {
+		hi = min(hi, *q.toRound)
+	}
+
+	// Start after the last round known to be too old and end before the
+	// first one known to be too new, so gaps next to the bounds are covered
+	if q.from != nil {
+		roundId, ok, err := api.db.LastRoundBefore(ctx, feed.Name, *q.from)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if ok {
+			lo = max(lo, roundId+1)
+		}
+	}
+	if q.to != nil {
+		roundId, ok, err := api.db.FirstRoundAfter(ctx, feed.Name, *q.to)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if ok {
+			hi = min(hi, max(roundId, 1)-1)
+		}
+	}
+	return lo, hi, fill, nil
+}
+
+// fetchRounds reads rounds from chain, a few at a time
+func (api *API) fetchRounds(ctx context.Context, feed *feeds.Feed, ids []uint64) ([]*db.OracleRound, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rounds := make([]*db.OracleRound, len(ids))
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, gapFillConcurrency)
+	var wg sync.WaitGroup
+
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = api.readRetry.Do(ctx, func(ctx context.Context) error {
+				_, answer, startedAt, updatedAt, answeredInRound, err := feed.Reader.GetRoundData(ctx, new(big.Int).SetUint64(id))
+				if err != nil {
+					return err
+				}
+				rounds[i] = &db.OracleRound{
+					Feed:            feed.Name,
+					RoundID:         id,
+					Answer:          answer.String(),
+					StartedAt:       time.Unix(startedAt.Int64(), 0),
+					UpdatedAt:       time.Unix(updatedAt.Int64(), 0),
+					AnsweredInRound: answeredInRound.Uint64(),
+				}
+				return nil
+			})
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the others
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return rounds, nil
+}
+
+// roundFromDB converts a stored round to its response form
+func roundFromDB(round *db.OracleRound) RoundData {
+	return RoundData{
+		RoundID:         round.RoundID,
+		Answer:          round.Answer,
+		StartedAt:       round.StartedAt.Unix(),
+		UpdatedAt:       round.UpdatedAt.Unix(),
+		AnsweredInRound: round.AnsweredInRound,
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/netip"
+	"sync/atomic"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/ratelimit"
+)
+
+// Tunables are the HTTP settings that may change while the server runs
+type Tunables struct {
+	// RateLimits are the request limits by client, API key scope and route
+	RateLimits ratelimit.Policy
+
+	// TrustedProxies may name the client in X-Forwarded-For and X-Real-IP
+	TrustedProxies []netip.Prefix
+
+	// How long latest and historical rounds stay cached
+	LatestCacheTTL time.Duration
+	RoundCacheTTL  time.Duration
+
+	// CORSOrigins are the origins allowed to call the API; "*" allows any
+	CORSOrigins []string
+}
+
+// Settings holds the current Tunables. The middleware and handlers read it on
+// every request, so a Store takes effect immediately.
+type Settings struct {
+	current atomic.Pointer[Tunables]
+}
+
+// NewSettings creates settings holding t
+func NewSettings(t Tunables) *Settings {
+	s := &Settings{}
+	s.Store(t)
+	return s
+}
+
+// Load returns the current tunables
+func (s *Settings) Load() Tunables {
+	return *s.current.Load()
+}
+
+// Store replaces the current tunables
+func (s *Settings) Store(t Tunables) {
+	s.current.Store(&t)
+}
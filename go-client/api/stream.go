@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/events"
+)
+
+const (
+	// streamKeepAlive is how often an idle stream sends a comment line
+	streamKeepAlive = 15 * time.Second
+
+	// streamBuffer is how many rounds a client may fall behind before it is disconnected
+	streamBuffer = 64
+
+	// streamBackfillPage is how many stored rounds are read per query on resume
+	streamBackfillPage = 500
+)
+
+// StreamPricesHandler handles GET /stream/prices and GET /feeds/{name}/stream/prices.
+// It sends every new round of the feed as a Server-Sent Event whose ID is the
+// round ID. A client reconnecting with Last-Event-ID first receives the stored
+// rounds it missed.
+func (api *API) StreamPricesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	var lastID uint64
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("lastEventId")
+	}
+	if resume != "" {
+		id, err := strconv.ParseUint(resume, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastID = id
+	}
+
+	rc := http.NewResponseController(w)
+
+	// Subscribe before backfilling so no round published in between is lost;
+	// duplicates are skipped by round ID below
+	sub := api.bus.Subscribe(streamBuffer, events.RoundsTopic(feed.Name))
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(ctx, "streaming unsupported", "feed", feed.Name, "err", err)
+		return
+	}
+
+	send := func(round *db.OracleRound) error {
+		if round.RoundID <= lastID {
+			return nil
+		}
+		data, err := json.Marshal(withPrice(feed, roundFromDB(round)))
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: round\ndata: %s\n\n", round.RoundID, data); err != nil {
+			return err
+		}
+		lastID = round.RoundID
+		return rc.Flush()
+	}
+
+	// Replay what the client missed
+	if resume != "" {
+		for {
+			rounds, err := api.db.GetRoundsAfter(ctx, feed.Name, lastID, streamBackfillPage)
+			if err != nil {
+				slog.ErrorContext(ctx, "stream backfill failed", "feed", feed.Name, "from_round", lastID, "err", err)
+				return
+			}
+			for _, round := range rounds {
+				if err := send(round); err != nil {
+					return
+				}
+			}
+			if len(rounds) < streamBackfillPage {
+				break
+			}
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C():
+			if !ok {
+				if sub.Slow() {
+					slog.WarnContext(ctx, "stream client fell behind, disconnecting", "feed", feed.Name, "client", clientIP(r, api.settings.Load().TrustedProxies))
+				}
+				return
+			}
+			round, ok := event.Data.(*db.OracleRound)
+			if !ok {
+				continue
+			}
+			if err := send(round); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/history"
+)
+
+// maxTWAPWindow bounds the rounds a single TWAP reads
+const maxTWAPWindow = 7 * 24 * time.Hour
+
+// TWAPRound is a round that contributed to a TWAP, with how long its answer
+// was in effect within the window
+type TWAPRound struct {
+	RoundID       uint64  `json:"roundId"`
+	Answer        string  `json:"answer"`
+	UpdatedAt     int64   `json:"updatedAt"`
+	WeightSeconds float64 `json:"weightSeconds"`
+}
+
+// TWAPResponse is a time-weighted average price. TWAP is a raw answer,
+// truncated to an integer.
+type TWAPResponse struct {
+	TWAP     string      `json:"twap"`
+	From     int64       `json:"from"`
+	To       int64       `json:"to"`
+	Coverage float64     `json:"coverage"`
+	Rounds   []TWAPRound `json:"rounds"`
+}
+
+// GetTWAPHandler handles GET /twap and GET /feeds/{name}/twap. window is a
+// duration such as 30m; at is unix seconds and defaults to now. Each answer
+// is weighted by how long it held within [at - window, at), until the next
+// round's updatedAt. coverage is the share of the window with a known
+// price, so callers can reject averages over too little data; spans where
+// rounds are missing and could not be read from chain count as unknown.
+func (api *API) GetTWAPHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	feed, ok := api.resolveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	values := r.URL.Query()
+	window, err := time.ParseDuration(values.Get("window"))
+	if err != nil || window <= 0 {
+		http.Error(w, "window must be a positive duration, e.g. 30m", http.StatusBadRequest)
+		return
+	}
+	if window > maxTWAPWindow {
+		http.Error(w, fmt.Sprintf("window must not exceed %s", maxTWAPWindow), http.StatusBadRequest)
+		return
+	}
+
+	at, err := parseTimeParam(values, "at")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end := time.Now()
+	if at != nil {
+		end = *at
+	}
+	start := end.Add(-window)
+
+	series, err := api.loadWindow(ctx, feed, start, end)
+	if err != nil {
+		serverError(w, r, http.StatusInternalServerError, "Failed to get rounds", err)
+		return
+	}
+
+	twap, ok := history.TimeWeighted(series.prev, series.points, series.gaps, start, end)
+	if !ok {
+		http.Error(w, "No known price during the window", http.StatusNotFound)
+		return
+	}
+
+	response := TWAPResponse{
+		TWAP:     twap.Value.String(),
+		From:     start.Unix(),
+		To:       end.Unix(),
+		Coverage: twap.Coverage,
+		Rounds:   make([]TWAPRound, 0, len(twap.Contributions)),
+	}
+	for _, c := range twap.Contributions {
+		response.Rounds = append(response.Rounds, TWAPRound{
+			RoundID:       c.RoundID,
+			Answer:        c.Answer.String(),
+			UpdatedAt:     c.UpdatedAt.Unix(),
+			WeightSeconds: c.Duration.Seconds(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,400 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/events"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait is how long a single message may take to write
+	wsWriteWait = 10 * time.Second
+
+	// wsPongWait is how long the client may stay silent before it is
+	// considered gone; pings are sent well within it
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+
+	// wsMaxMessageSize bounds client messages
+	wsMaxMessageSize = 4096
+
+	// wsReplyBuffer is how many unsent replies to client requests may queue up
+	wsReplyBuffer = 64
+)
+
+// WebSocket message types
+const (
+	WSSubscribe    = "subscribe"
+	WSUnsubscribe  = "unsubscribe"
+	WSSubscribed   = "subscribed"
+	WSUnsubscribed = "unsubscribed"
+	WSRound        = "round"
+	WSTx           = "tx"
+	WSHealth       = "health"
+	WSError        = "error"
+)
+
+// CORSMiddleware already allows any origin, so the upgrade does too
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// WSRequest is a message sent by a WebSocket client. Topics are
+// "rounds:<feed>", "tx:<hash>" or "health".
+type WSRequest struct {
+	Type   string   `json:"type"`
+	Topics []string `json:"topics"`
+}
+
+// WSMessage is a message sent to a WebSocket client. Data is a RoundData for
+// rounds, an UpdatePriceResponse for transactions and a HealthResponse for health.
+type WSMessage struct {
+	Type   string      `json:"type"`
+	Topic  string      `json:"topic,omitempty"`
+	Topics []string    `json:"topics,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// WebSocketHandler handles GET /ws. Clients subscribe and unsubscribe to
+// topics and receive the current state of each topic on subscribe, followed
+// by every change. A client that falls too far behind is disconnected.
+func (api *API) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error
+		return
+	}
+	defer conn.Close()
+
+	// The request context is not cancelled when a hijacked connection closes,
+	// so the read loop cancels this one instead. It keeps the request ID.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+
+	sub := api.bus.Subscribe(api.wsSendBuffer)
+	defer sub.Close()
+
+	replies := make(chan *WSMessage, wsReplyBuffer)
+	go api.wsReadLoop(ctx, cancel, conn, sub, replies)
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg := <-replies:
+			if err := wsWrite(conn, msg); err != nil {
+				return
+			}
+
+		case event, ok := <-sub.C():
+			if !ok {
+				reason := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				if sub.Slow() {
+					slog.WarnContext(ctx, "ws client fell behind, disconnecting", "client", clientIP(r, api.settings.Load().TrustedProxies))
+					reason = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too slow to keep up")
+				}
+				conn.WriteControl(websocket.CloseMessage, reason, time.Now().Add(wsWriteWait))
+				return
+			}
+
+			msg := api.eventMessage(ctx, event)
+			if msg == nil {
+				continue
+			}
+			if err := wsWrite(conn, msg); err != nil {
+				return
+			}
+			api.followReplacement(ctx, sub, event, replies)
+
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsReadLoop handles client requests until the connection fails or ctx is done
+func (api *API) wsReadLoop(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, sub *events.Subscription, replies chan<- *WSMessage) {
+	defer cancel()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var msgs []*WSMessage
+		var req WSRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			msgs = []*WSMessage{{Type: WSError, Error: "invalid JSON message"}}
+		} else {
+			msgs = api.handleWSRequest(ctx, sub, &req)
+		}
+
+		for _, msg := range msgs {
+			select {
+			case replies <- msg:
+			case <-ctx.Done():
+				return
+			default:
+				// The client keeps sending requests without reading the replies
+				return
+			}
+		}
+	}
+}
+
+// handleWSRequest applies a subscribe or unsubscribe request and returns the
+// replies, including the current state of every newly subscribed topic
+func (api *API) handleWSRequest(ctx context.Context, sub *events.Subscription, req *WSRequest) []*WSMessage {
+	if req.Type != WSSubscribe && req.Type != WSUnsubscribe {
+		return []*WSMessage{{Type: WSError, Error: fmt.Sprintf("unknown message type %q", req.Type)}}
+	}
+	if len(req.Topics) == 0 {
+		return []*WSMessage{{Type: WSError, Error: "topics is required"}}
+	}
+
+	topics := make([]string, 0, len(req.Topics))
+	for _, raw := range req.Topics {
+		topic, err := api.parseTopic(raw)
+		if err != nil {
+			return []*WSMessage{{Type: WSError, Error: err.Error()}}
+		}
+		topics = append(topics, topic)
+	}
+
+	if req.Type == WSUnsubscribe {
+		sub.Remove(topics...)
+		return []*WSMessage{{Type: WSUnsubscribed, Topics: topics}}
+	}
+
+	var added []string
+	for _, topic := range topics {
+		if !sub.Has(topic) {
+			added = append(added, topic)
+		}
+	}
+	if sub.Topics()+len(added) > api.wsMaxTopics {
+		return []*WSMessage{{Type: WSError, Error: fmt.Sprintf("at most %d topics per connection", api.wsMaxTopics)}}
+	}
+	sub.Add(added...)
+
+	// Snapshots are read after subscribing, so a change in between may be
+	// delivered twice but is never missed
+	msgs := []*WSMessage{{Type: WSSubscribed, Topics: topics}}
+	for _, topic := range added {
+		msg, err := api.snapshot(ctx, topic)
+		if err != nil {
+			slog.ErrorContext(ctx, "ws failed to read current state", "topic", topic, "err", err)
+			continue
+		}
+		if msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// parseTopic validates a client topic and returns its bus topic
+func (api *API) parseTopic(topic string) (string, error) {
+	if topic == events.HealthTopic {
+		return topic, nil
+	}
+
+	kind, arg, _ := strings.Cut(topic, ":")
+	switch kind {
+	case "rounds":
+		if _, ok := api.feeds.Get(arg); !ok {
+			return "", fmt.Errorf("unknown feed %q", arg)
+		}
+		return events.RoundsTopic(arg), nil
+	case "tx":
+		if !isTxHash(arg) {
+			return "", fmt.Errorf("invalid transaction hash %q", arg)
+		}
+		return events.TxTopic(common.HexToHash(arg).Hex()), nil
+	}
+	return "", fmt.Errorf("unknown topic %q", topic)
+}
+
+// snapshot returns the current state of a topic, or nil if there is none yet
+func (api *API) snapshot(ctx context.Context, topic string) (*WSMessage, error) {
+	if topic == events.HealthTopic {
+		api.healthMu.Lock()
+		last := api.lastHealth
+		api.healthMu.Unlock()
+
+		if last == nil {
+			health := api.checkHealth(ctx)
+			last = &health
+		}
+		return &WSMessage{Type: WSHealth, Topic: topic, Data: last}, nil
+	}
+
+	kind, arg, _ := strings.Cut(topic, ":")
+	switch kind {
+	case "rounds":
+		round, err := api.db.GetLatest(ctx, arg)
+		if err != nil || round == nil {
+			return nil, err
+		}
+		return api.eventMessage(ctx, events.Event{Topic: topic, Data: round}), nil
+	case "tx":
+		rec, err := api.db.GetTransaction(ctx, arg)
+		if err != nil || rec == nil {
+			return nil, err
+		}
+		return api.eventMessage(ctx, events.Event{Topic: topic, Data: &events.TxUpdate{Transaction: rec}}), nil
+	}
+	return nil, nil
+}
+
+// eventMessage converts a bus event into the message sent to clients
+func (api *API) eventMessage(ctx context.Context, event events.Event) *WSMessage {
+	switch data := event.Data.(type) {
+	case *db.OracleRound:
+		round := roundFromDB(data)
+		if feed, ok := api.feeds.Get(data.Feed); ok {
+			round = withPrice(feed, round)
+		}
+		return &WSMessage{Type: WSRound, Topic: event.Topic, Data: round}
+	case *events.TxUpdate:
+		return &WSMessage{Type: WSTx, Topic: event.Topic, Data: api.txResponse(ctx, data)}
+	case HealthResponse:
+		return &WSMessage{Type: WSHealth, Topic: event.Topic, Data: data}
+	}
+	return nil
+}
+
+// txResponse builds the UpdatePriceResponse for a transaction, adding the
+// round it created once it is confirmed
+func (api *API) txResponse(ctx context.Context, update *events.TxUpdate) UpdatePriceResponse {
+	rec := update.Transaction
+	response := UpdatePriceResponse{TxHash: rec.TxHash, Status: rec.Status}
+	if rec.Status != db.TxConfirmed {
+		return response
+	}
+
+	feed, ok := api.feeds.Get(rec.Feed)
+	if !ok || feed.Updater == nil {
+		return response
+	}
+
+	receipt := update.Receipt
+	if receipt == nil {
+		// Confirmed records return at once, with their receipt
+		waitCtx, cancel := context.WithTimeout(ctx, wsWriteWait)
+		defer cancel()
+
+		_, r, err := feed.Updater.WaitForTransaction(waitCtx, common.HexToHash(rec.TxHash))
+		if err != nil {
+			slog.ErrorContext(ctx, "ws failed to read receipt", "tx", rec.TxHash, "err", err)
+			return response
+		}
+		receipt = r
+	}
+	if receipt == nil {
+		return response
+	}
+
+	event, err := feed.Updater.ParseAnswerUpdated(receipt)
+	if err != nil {
+		slog.ErrorContext(ctx, "ws failed to read update event", "tx", rec.TxHash, "err", err)
+		return response
+	}
+	response.RoundID = event.RoundId.Uint64()
+	response.Answer = event.Current.String()
+	response.UpdatedAt = event.UpdatedAt.Int64()
+	return response
+}
+
+// followReplacement subscribes to the fee-bump replacement of a transaction
+// the client is following and sends its current state
+func (api *API) followReplacement(ctx context.Context, sub *events.Subscription, event events.Event, replies chan<- *WSMessage) {
+	update, ok := event.Data.(*events.TxUpdate)
+	if !ok || update.Transaction.ReplacedBy == "" {
+		return
+	}
+
+	topic := events.TxTopic(update.Transaction.ReplacedBy)
+	if sub.Has(topic) {
+		return
+	}
+	sub.Add(topic)
+
+	msg, err := api.snapshot(ctx, topic)
+	if err != nil || msg == nil {
+		return
+	}
+	select {
+	case replies <- msg:
+	default:
+	}
+}
+
+// RunHealthMonitor checks health every interval and publishes a snapshot on
+// the health topic whenever it changes, until ctx is cancelled
+func (api *API) RunHealthMonitor(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		health := api.checkHealth(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		api.healthMu.Lock()
+		previous := api.lastHealth
+		api.lastHealth = &health
+		api.healthMu.Unlock()
+
+		if previous == nil || !reflect.DeepEqual(*previous, health) {
+			if previous != nil {
+				slog.InfoContext(ctx, "Health changed", "rpc", health.RPCConnected, "redis", health.RedisConnected, "postgres", health.PostgresConnected)
+			}
+			api.bus.Publish(events.HealthTopic, health)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// wsWrite sends msg as JSON within the write deadline
+func wsWrite(conn *websocket.Conn, msg *WSMessage) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(msg)
+}
@@ -0,0 +1,95 @@
+// Command devsigner is a local stand-in for Clef. It answers
+// account_signTransaction, account_list and account_version over HTTP with a
+// raw key or an encrypted keystore, without asking for approval, so the
+// server's remote signer can be exercised on a development chain. Never
+// expose it beyond localhost.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/114windd/oracle-client/internal/signer"
+	"github.com/ethereum/go-ethereum/common"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// accountService implements the account_ namespace for one key
+type accountService struct {
+	signer *signer.KeySigner
+}
+
+// Version returns the external API version Clef reports
+func (s *accountService) Version() string {
+	return "6.1.0"
+}
+
+// List returns the one account this stand-in signs for
+func (s *accountService) List() []common.Address {
+	return []common.Address{s.signer.Address()}
+}
+
+// SignTransaction signs the transaction args describe. methodSelector is
+// accepted for compatibility with Clef and ignored.
+func (s *accountService) SignTransaction(ctx context.Context, args signer.SendTxArgs, methodSelector *string) (*signer.SignTxResult, error) {
+	if args.From.Address() != s.signer.Address() {
+		return nil, errors.New("unknown account")
+	}
+	tx, err := args.Transaction()
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := s.signer.SignTx(ctx, tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "signed transaction", "from", s.signer.Address().Hex(), "tx", signed.Hash().Hex(), "nonce", signed.Nonce())
+	return &signer.SignTxResult{Raw: raw, Tx: signed}, nil
+}
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8550", "listen address")
+	privateKey := flag.String("key", os.Getenv("DEVSIGNER_PRIVATE_KEY"), "hex private key (default: $DEVSIGNER_PRIVATE_KEY)")
+	keystoreFile := flag.String("keystore", "", "encrypted JSON keystore file, instead of -key")
+	passwordFile := flag.String("password", "", "file holding the keystore password")
+	flag.Parse()
+
+	var (
+		keySigner *signer.KeySigner
+		err       error
+	)
+	if *keystoreFile != "" {
+		keySigner, err = signer.NewKeystoreSigner(*keystoreFile, *passwordFile)
+	} else {
+		keySigner, err = signer.NewKeySigner(*privateKey)
+	}
+	if err != nil {
+		fatal("Failed to load key", "err", err)
+	}
+
+	server := gethrpc.NewServer()
+	if err := server.RegisterName("account", &accountService{signer: keySigner}); err != nil {
+		fatal("Failed to register signer API", "err", err)
+	}
+
+	slog.Info("Starting signer stand-in", "addr", *addr, "account", keySigner.Address().Hex())
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		fatal("Signer stand-in stopped", "err", err)
+	}
+}
+
+// fatal logs msg at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
+	"math/big"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,66 +13,316 @@ import (
 
 	"github.com/114windd/oracle-client/api"
 	"github.com/114windd/oracle-client/config"
+	"github.com/114windd/oracle-client/internal/apikeys"
 	"github.com/114windd/oracle-client/internal/cache"
 	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/events"
+	"github.com/114windd/oracle-client/internal/feeds"
+	"github.com/114windd/oracle-client/internal/indexer"
+	"github.com/114windd/oracle-client/internal/logging"
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/nonce"
+	"github.com/114windd/oracle-client/internal/pusher"
+	"github.com/114windd/oracle-client/internal/ratelimit"
 	"github.com/114windd/oracle-client/internal/reader"
+	"github.com/114windd/oracle-client/internal/retry"
+	"github.com/114windd/oracle-client/internal/rpc"
+	"github.com/114windd/oracle-client/internal/signer"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"github.com/114windd/oracle-client/internal/txtracker"
 	"github.com/114windd/oracle-client/internal/updater"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func main() {
+	configFile := flag.String("config", "", "YAML config file; environment variables override it (default: $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, with secrets redacted, and exit")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", "err", err)
+	}
+	if *printConfig {
+		if err := cfg.Dump(os.Stdout); err != nil {
+			fatal("Failed to print config", "err", err)
+		}
+		return
+	}
+
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fatal("Failed to set up logging", "err", err)
+	}
+	if cfg.File != "" {
+		slog.Info("Loaded config file", "path", cfg.File)
 	}
+	var effective []any
+	for _, setting := range cfg.RedactedSettings() {
+		effective = append(effective, slog.String(setting.Key, setting.Value))
+	}
+	slog.Debug("Effective configuration", effective...)
 
-	// Create Ethereum client
-	client, err := ethclient.Dial(cfg.RPCURL)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.TracingExporter,
+		File:        cfg.TracingFile,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		log.Fatalf("Failed to connect to Ethereum client: %v", err)
+		fatal("Failed to set up tracing", "err", err)
 	}
-	defer client.Close()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "err", err)
+		}
+	}()
 
-	// Parse contract address
-	contractAddress := common.HexToAddress(cfg.ContractAddress)
+	// Create a pool of RPC providers per chain, each behind a circuit breaker
+	clients := make(map[string]*ethclient.Client)
+	pools := make(map[string]*rpc.Pool)
+	for name, spec := range cfg.Chains {
+		endpoints, err := rpc.ParseEndpoints(spec)
+		if err != nil {
+			fatal("Invalid RPC endpoints", "chain", name, "err", err)
+		}
+		pool, err := rpc.NewPool(context.Background(), name, endpoints, rpc.PoolConfig{
+			Breaker: rpc.BreakerConfig{
+				FailureThreshold: cfg.RPCBreakerFailures,
+				OpenTimeout:      cfg.RPCBreakerOpenTimeout,
+				HalfOpenProbes:   cfg.RPCBreakerHalfOpenProbes,
+			},
+			MaxBlockLag:   cfg.RPCMaxBlockLag,
+			CheckInterval: cfg.RPCHealthCheckInterval,
+		})
+		if err != nil {
+			fatal("Failed to connect to Ethereum client", "chain", name, "err", err)
+		}
+		defer pool.Close()
+		pools[name] = pool
+		clients[name] = pool.Client()
+	}
 
-	// Create reader
-	reader, err := reader.NewReader(client, contractAddress)
+	// Create Postgres database
+	dbClient, err := db.New(cfg.PostgresHost, cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresDB, cfg.PostgresPort)
 	if err != nil {
-		log.Fatalf("Failed to create reader: %v", err)
+		fatal("Failed to create database", "err", err)
 	}
+	defer dbClient.Close()
 
-	// Create updater
-	updater, err := updater.NewUpdater(client, contractAddress, cfg.PrivateKey)
-	if err != nil {
-		log.Fatalf("Failed to create updater: %v", err)
+	// Rounds, transaction status and health changes are fanned out to
+	// streaming clients
+	bus := events.NewBus()
+
+	// Create a transaction tracker and nonce registry per chain
+	trackers := make(map[string]*txtracker.Tracker)
+	nonces := make(map[string]*nonce.Registry)
+	for name, client := range clients {
+		trackers[name] = txtracker.New(client, dbClient, bus, name, cfg.TxConfirmations, cfg.TxPollInterval, cfg.TxDropTimeout)
+		nonces[name] = nonce.NewRegistry(client, trackers[name])
+	}
+
+	fees := updater.FeeConfig{
+		MaxFeePerGas:         gweiToWei(cfg.TxMaxFeeGwei),
+		MaxPriorityFeePerGas: gweiToWei(cfg.TxMaxPriorityFeeGwei),
+		GasLimitMargin:       cfg.TxGasLimitMargin,
+		BumpAfterBlocks:      cfg.TxBumpAfterBlocks,
+		BumpPercent:          cfg.TxBumpPercent,
+		BumpInterval:         cfg.TxBumpInterval,
+	}
+
+	// Build the feed registry
+	registry := feeds.NewRegistry()
+	for _, feedCfg := range cfg.Feeds {
+		feed, err := newFeed(clients[feedCfg.Chain], trackers[feedCfg.Chain], nonces[feedCfg.Chain], fees, feedCfg)
+		if err != nil {
+			fatal("Failed to create feed", "feed", feedCfg.Name, "err", err)
+		}
+		feed.RPC = pools[feedCfg.Chain]
+		if cfg.RPCReadQuorum > 1 {
+			if err := feed.Reader.EnableQuorum(feed.RPC.ProviderClients(), cfg.RPCReadQuorum); err != nil {
+				fatal("Failed to enable read quorum", "feed", feedCfg.Name, "err", err)
+			}
+		}
+		if err := registry.Add(feed); err != nil {
+			fatal("Failed to register feed", "feed", feedCfg.Name, "err", err)
+		}
 	}
 
-	// Create Redis cache
-	cacheClient := cache.New(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	// Create cache
+	cacheClient, err := cache.New(cache.Options{
+		Backend:       cfg.CacheBackend,
+		RedisAddr:     cfg.RedisAddr,
+		RedisPassword: cfg.RedisPassword,
+		RedisDB:       cfg.RedisDB,
+		MaxEntries:    cfg.CacheMaxEntries,
+	})
+	if err != nil {
+		fatal("Failed to create cache", "err", err)
+	}
 	defer cacheClient.Close()
 
-	// Create Postgres database
-	dbClient, err := db.New(cfg.PostgresHost, cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresDB, cfg.PostgresPort)
+	// Create rate limiter
+	limiter, err := ratelimit.New(ratelimit.Options{
+		Backend:       cfg.RateLimitBackend,
+		RedisAddr:     cfg.RedisAddr,
+		RedisPassword: cfg.RedisPassword,
+		RedisDB:       cfg.RedisDB,
+	})
 	if err != nil {
-		log.Fatalf("Failed to create database: %v", err)
+		fatal("Failed to create rate limiter", "err", err)
+	}
+	defer limiter.Close()
+
+	// Background workers stop when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	for name, pool := range pools {
+		go func() {
+			if err := pool.Run(workerCtx); err != nil && err != context.Canceled {
+				slog.Error("RPC health checks stopped", "chain", name, "err", err)
+			}
+		}()
+	}
+
+	for name, tracker := range trackers {
+		go func() {
+			if err := tracker.Run(workerCtx); err != nil && err != context.Canceled {
+				slog.Error("Transaction tracker stopped", "chain", name, "err", err)
+			}
+		}()
+	}
+
+	// Read each feed's decimals up front, so round responses carry prices
+	// without waiting on the node
+	for _, feed := range registry.All() {
+		go func() {
+			if err := feed.Reader.PreloadMetadata(workerCtx); err != nil && err != context.Canceled {
+				slog.Error("Metadata preload stopped", "feed", feed.Name, "err", err)
+			}
+		}()
+	}
+
+	// Report signer balances and bump stuck fees per updatable feed
+	for _, feed := range registry.All() {
+		if feed.Updater == nil {
+			continue
+		}
+		go func() {
+			if err := feed.Updater.Run(workerCtx); err != nil && err != context.Canceled {
+				slog.Error("Updater stopped", "feed", feed.Name, "err", err)
+			}
+		}()
+	}
+
+	// Start a price pusher for every feed with configured sources
+	for _, feedCfg := range cfg.Feeds {
+		if feedCfg.PusherSources == "" {
+			continue
+		}
+
+		feed, _ := registry.Get(feedCfg.Name)
+		sources, err := pusher.ParseSourceList(feedCfg.PusherSources, cfg.PusherSourceTimeout)
+		if err != nil {
+			fatal("Failed to create price sources", "feed", feed.Name, "err", err)
+		}
+
+		aggregator, err := pusher.NewAggregator(sources, pusher.AggregatorConfig{
+			Quorum:        feedCfg.PusherQuorum,
+			MaxAge:        cfg.PusherMaxQuoteAge,
+			OutlierMethod: cfg.PusherOutlierMethod,
+			Threshold:     cfg.PusherOutlierThreshold,
+			MinBand:       cfg.PusherOutlierMinBand,
+		})
+		if err != nil {
+			fatal("Failed to create price aggregator", "feed", feed.Name, "err", err)
+		}
+
+		feedPusher, err := pusher.New(feed, aggregator, feedCfg.PusherDeviation, feedCfg.PusherHeartbeat, cfg.PusherPollInterval, cfg.TxWaitTimeout)
+		if err != nil {
+			fatal("Failed to create pusher", "feed", feed.Name, "err", err)
+		}
+
+		go func() {
+			if err := feedPusher.Run(workerCtx); err != nil && err != context.Canceled {
+				slog.Error("Pusher stopped", "feed", feed.Name, "err", err)
+			}
+		}()
+	}
+
+	// Start an AnswerUpdated indexer per feed
+	if !cfg.IndexerEnabled {
+		slog.Info("Indexer disabled; /stream/prices will only replay stored rounds")
+	} else {
+		for _, feed := range registry.All() {
+			roundIndexer, err := indexer.New(clients[feed.Chain], feed.Name, feed.Address, dbClient, bus, cfg.IndexerStartBlock, cfg.IndexerBatchSize, cfg.IndexerConfirmations, cfg.IndexerPollInterval)
+			if err != nil {
+				fatal("Failed to create indexer", "feed", feed.Name, "err", err)
+			}
+
+			go func(name string) {
+				if err := roundIndexer.Run(workerCtx); err != nil && err != context.Canceled {
+					slog.Error("Indexer stopped", "feed", name, "err", err)
+				}
+			}(feed.Name)
+		}
 	}
-	defer dbClient.Close()
 
 	// Create API
-	apiInstance := api.New(reader, updater, cacheClient, dbClient)
+	readRetry := retry.Policy{
+		MaxAttempts:    cfg.RetryReadAttempts,
+		BaseDelay:      cfg.RetryReadBaseDelay,
+		MaxDelay:       cfg.RetryReadMaxDelay,
+		Multiplier:     2,
+		AttemptTimeout: cfg.RetryReadAttemptTimeout,
+		Retryable:      retry.IsRetryable,
+	}
+	txRetry := retry.Policy{
+		MaxAttempts:    cfg.RetryTxAttempts,
+		BaseDelay:      cfg.RetryTxBaseDelay,
+		MaxDelay:       cfg.RetryTxMaxDelay,
+		Multiplier:     2,
+		AttemptTimeout: cfg.RetryTxAttemptTimeout,
+		Retryable:      retry.IsTxRetryable,
+	}
+
+	settings := api.NewSettings(tunables(cfg))
+	apiInstance := api.New(registry, cacheClient, dbClient, bus, settings, cfg.TxWaitTimeout, readRetry, txRetry, cfg.WSMaxTopics, cfg.WSSendBuffer)
+
+	// Publish health transitions to WebSocket subscribers
+	go func() {
+		if err := apiInstance.RunHealthMonitor(workerCtx, cfg.HealthCheckInterval); err != nil && err != context.Canceled {
+			slog.Error("Health monitor stopped", "err", err)
+		}
+	}()
 
 	// Setup routes
 	mux := http.NewServeMux()
 
 	// Apply middleware
-	handler := api.CORSMiddleware(
-		api.LoggingMiddleware(
-			api.RateLimitMiddleware(
-				api.AuthMiddleware(cfg.APIKey)(
-					setupRoutes(mux, apiInstance),
+	// TracingMiddleware is the last one to copy the request, apart from
+	// AuthMiddleware which hands the matched route back, so the route the
+	// mux matches is visible to it and to MetricsMiddleware. Rate limits
+	// are per API key, so RateLimitMiddleware runs after AuthMiddleware;
+	// IPRateLimitMiddleware runs before it, so failed attempts count too.
+	handler := api.CORSMiddleware(settings)(
+		api.RequestIDMiddleware(
+			api.TracingMiddleware(
+				api.MetricsMiddleware(
+					api.LoggingMiddleware(
+						api.IPRateLimitMiddleware(limiter, settings, mux)(
+							api.AuthMiddleware(dbClient, cfg.APIKey)(
+								api.RateLimitMiddleware(limiter, settings, mux)(
+									setupRoutes(mux, apiInstance),
+								),
+							),
+						),
+					),
 				),
 			),
 		),
@@ -84,35 +336,167 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on port %s", cfg.ServerPort)
+		slog.Info("Starting server", "port", cfg.ServerPort)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("Failed to start server", "err", err)
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
+	// Reload the settings that are safe to change on SIGHUP, and wait for
+	// an interrupt signal to gracefully shutdown the server
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
+	for waiting := true; waiting; {
+		select {
+		case <-hup:
+			reloadConfig(cfg, settings)
+		case <-quit:
+			waiting = false
+		}
+	}
+	slog.Info("Shutting down server")
+	stopWorkers()
+
+	// End open streams so Shutdown does not wait on them
+	bus.Close()
 
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", "err", err)
+	}
+
+	slog.Info("Server exited")
+}
+
+// tunables picks the settings a running server can change out of cfg
+func tunables(cfg *config.Config) api.Tunables {
+	return api.Tunables{
+		RateLimits: ratelimit.Policy{
+			Default: ratelimit.Limit{Requests: cfg.RateLimitRequests, Window: cfg.RateLimitWindow},
+			Scopes:  cfg.RateLimitScopes,
+			Routes:  cfg.RateLimitRoutes,
+			IP:      cfg.RateLimitIP,
+		},
+		TrustedProxies: cfg.TrustedProxies,
+		LatestCacheTTL: cfg.CacheLatestTTL,
+		RoundCacheTTL:  cfg.CacheRoundTTL,
+		CORSOrigins:    cfg.CORSAllowedOrigins,
+	}
+}
+
+// reloadConfig loads the configuration again and applies its reloadable
+// settings; running is the configuration the server started with. An
+// invalid configuration is rejected whole.
+func reloadConfig(running *config.Config, settings *api.Settings) {
+	next, err := config.LoadConfig(running.File)
+	if err != nil {
+		slog.Error("Config reload failed, keeping the current settings", "err", err)
+		return
+	}
+
+	logging.SetLevel(next.LogLevel)
+	settings.Store(tunables(next))
+
+	if changed := running.RestartRequired(next); len(changed) > 0 {
+		slog.Warn("Config changes need a restart to take effect", "keys", changed)
+	}
+	slog.Info("Config reloaded", "applied", config.Reloadable)
+}
+
+// fatal logs msg at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// newFeed creates the reader, and the updater if a signer is configured, for one feed
+func newFeed(client *ethclient.Client, tracker *txtracker.Tracker, nonces *nonce.Registry, fees updater.FeeConfig, feedCfg config.FeedConfig) (*feeds.Feed, error) {
+	contractAddress := common.HexToAddress(feedCfg.Address)
+
+	feedReader, err := reader.NewReader(client, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &feeds.Feed{
+		Name:        feedCfg.Name,
+		Address:     contractAddress,
+		Chain:       feedCfg.Chain,
+		Reader:      feedReader,
+		MaxPriceAge: feedCfg.MaxPriceAge,
+	}
+
+	if feedCfg.Signer.Configured() {
+		txSigner, err := signer.New(context.Background(), signer.Options{
+			PrivateKey:   feedCfg.Signer.PrivateKey,
+			Keystore:     feedCfg.Signer.Keystore,
+			PasswordFile: feedCfg.Signer.KeystorePasswordFile,
+			URL:          feedCfg.Signer.URL,
+			Address:      common.HexToAddress(feedCfg.Signer.Address),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		feed.Updater, err = updater.NewUpdater(client, contractAddress, txSigner, feedCfg.Name, tracker, nonces, fees)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	log.Println("Server exited")
+	return feed, nil
+}
+
+// gweiToWei converts a gwei amount from config to wei; zero means unset
+func gweiToWei(gwei uint64) *big.Int {
+	if gwei == 0 {
+		return nil
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(gwei), big.NewInt(params.GWei))
 }
 
-// setupRoutes configures the HTTP routes
+// setupRoutes configures the HTTP routes. The un-namespaced routes serve the
+// default feed. Every route but /health and /metrics requires an API key
+// scope.
 func setupRoutes(mux *http.ServeMux, apiInstance *api.API) http.Handler {
-	mux.HandleFunc("/latestPrice", apiInstance.GetLatestPriceHandler)
-	mux.HandleFunc("/round/", apiInstance.GetRoundDataHandler)
-	mux.HandleFunc("/updatePrice", apiInstance.UpdatePriceHandler)
+	read := func(h http.HandlerFunc) http.Handler { return api.RequireScope(apikeys.ScopeRead, h) }
+	update := func(h http.HandlerFunc) http.Handler { return api.RequireScope(apikeys.ScopeUpdate, h) }
+	admin := func(h http.HandlerFunc) http.Handler { return api.RequireScope(apikeys.ScopeAdmin, h) }
+
+	mux.Handle("/latestPrice", read(apiInstance.GetLatestPriceHandler))
+	mux.Handle("/round/{id}", read(apiInstance.GetRoundDataHandler))
+	mux.Handle("/rounds", read(apiInstance.ListRoundsHandler))
+	mux.Handle("/candles", read(apiInstance.GetCandlesHandler))
+	mux.Handle("/twap", read(apiInstance.GetTWAPHandler))
+	mux.Handle("/metadata", read(apiInstance.MetadataHandler))
+	mux.Handle("/updatePrice", update(apiInstance.UpdatePriceHandler))
 	mux.HandleFunc("/health", apiInstance.HealthHandler)
+	mux.Handle("/providers", read(apiInstance.ProvidersHandler))
+	mux.Handle("/tx/{hash}", read(apiInstance.GetTransactionHandler))
+
+	mux.Handle("/feeds", read(apiInstance.ListFeedsHandler))
+	mux.Handle("/feeds/{name}/latestPrice", read(apiInstance.GetLatestPriceHandler))
+	mux.Handle("/feeds/{name}/round/{id}", read(apiInstance.GetRoundDataHandler))
+	mux.Handle("/feeds/{name}/rounds", read(apiInstance.ListRoundsHandler))
+	mux.Handle("/feeds/{name}/candles", read(apiInstance.GetCandlesHandler))
+	mux.Handle("/feeds/{name}/twap", read(apiInstance.GetTWAPHandler))
+	mux.Handle("/feeds/{name}/metadata", read(apiInstance.MetadataHandler))
+	mux.Handle("/feeds/{name}/updatePrice", update(apiInstance.UpdatePriceHandler))
+
+	mux.Handle("/stream/prices", read(apiInstance.StreamPricesHandler))
+	mux.Handle("/feeds/{name}/stream/prices", read(apiInstance.StreamPricesHandler))
+	mux.Handle("/ws", read(apiInstance.WebSocketHandler))
+
+	mux.Handle("POST /admin/keys", admin(apiInstance.CreateKeyHandler))
+	mux.Handle("GET /admin/keys", admin(apiInstance.ListKeysHandler))
+	mux.Handle("DELETE /admin/keys/{id}", admin(apiInstance.RevokeKeyHandler))
+
+	mux.Handle("/metrics", metrics.Handler())
 
 	return mux
 }
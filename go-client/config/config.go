@@ -1,22 +1,55 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"net/netip"
 	"os"
-	"strconv"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/114windd/oracle-client/internal/ratelimit"
 )
 
 // Config holds the application configuration
 type Config struct {
+	// File is the config file the configuration was read from, if any
+	File string
+	// Settings holds every key's effective value, in the order read
+	Settings []Setting
+
 	RPCURL          string
-	PrivateKey      string
+	Signer          SignerConfig // signer of the default feed
 	ContractAddress string
 	ServerPort      string
 	APIKey          string
 
+	// Logging configuration
+	LogLevel  string
+	LogFormat string
+
+	// Tracing configuration
+	TracingExporter    string
+	TracingFile        string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// Cache configuration
+	CacheBackend    string
+	CacheMaxEntries int
+	CacheLatestTTL  time.Duration
+	CacheRoundTTL   time.Duration
+
+	// HTTP limits; everything but RateLimitBackend is reloaded on SIGHUP
+	// along with CacheLatestTTL, CacheRoundTTL and LogLevel
+	RateLimitBackend   string
+	RateLimitRequests  int
+	RateLimitWindow    time.Duration
+	RateLimitScopes    map[string]ratelimit.Limit // by API key scope
+	RateLimitRoutes    map[string]ratelimit.Limit // by route pattern
+	RateLimitIP        ratelimit.Limit            // by IP, before authentication
+	TrustedProxies     []netip.Prefix             // may set X-Forwarded-For
+	CORSAllowedOrigins []string
+
 	// Redis configuration
 	RedisAddr     string
 	RedisPassword string
@@ -28,71 +61,207 @@ type Config struct {
 	PostgresUser     string
 	PostgresPassword string
 	PostgresDB       string
+
+	// Indexer configuration
+	IndexerEnabled       bool
+	IndexerStartBlock    uint64
+	IndexerBatchSize     uint64
+	IndexerPollInterval  time.Duration
+	IndexerConfirmations uint64
+
+	// Transaction tracking configuration
+	TxConfirmations uint64
+	TxPollInterval  time.Duration
+	TxDropTimeout   time.Duration
+	TxWaitTimeout   time.Duration
+
+	// Transaction fee configuration
+	TxMaxFeeGwei         uint64
+	TxMaxPriorityFeeGwei uint64
+	TxGasLimitMargin     uint64
+	TxBumpAfterBlocks    uint64
+	TxBumpPercent        uint64
+	TxBumpInterval       time.Duration
+
+	// RPC circuit breaker configuration
+	RPCBreakerFailures       int
+	RPCBreakerOpenTimeout    time.Duration
+	RPCBreakerHalfOpenProbes int
+
+	// RPC provider pool configuration
+	RPCMaxBlockLag         uint64
+	RPCHealthCheckInterval time.Duration
+	RPCReadQuorum          int
+
+	// Retry policies for contract reads and transaction submission
+	RetryReadAttempts       int
+	RetryReadBaseDelay      time.Duration
+	RetryReadMaxDelay       time.Duration
+	RetryReadAttemptTimeout time.Duration
+	RetryTxAttempts         int
+	RetryTxBaseDelay        time.Duration
+	RetryTxMaxDelay         time.Duration
+	RetryTxAttemptTimeout   time.Duration
+
+	// Pusher configuration; sources and thresholds are set per feed
+	PusherPollInterval     time.Duration
+	PusherSourceTimeout    time.Duration
+	PusherMaxQuoteAge      time.Duration
+	PusherOutlierMethod    string
+	PusherOutlierThreshold float64
+	PusherOutlierMinBand   float64
+
+	// Streaming configuration
+	HealthCheckInterval time.Duration
+	WSMaxTopics         int
+	WSSendBuffer        int
+
+	// Feed registry
+	Chains map[string]string // chain name -> comma-separated RPC endpoints
+	Feeds  []FeedConfig
 }
 
-// LoadConfig loads configuration from environment variables and .env file
-func LoadConfig() (*Config, error) {
-	// Try to load .env file from multiple possible locations
-	envFiles := []string{".env", "../.env", "../../.env"}
-	var loaded bool
-
-	for _, envFile := range envFiles {
-		if err := godotenv.Load(envFile); err == nil {
-			log.Printf("Successfully loaded .env file from: %s", envFile)
-			loaded = true
-			break
-		}
+// LoadConfig loads configuration from environment variables, the .env file
+// and the YAML config file at path, falling back to CONFIG_FILE when path is
+// empty. Environment variables override the .env file, which overrides the
+// config file. Both files are read again on every call, so a reload picks up
+// edits to either. Every value is parsed and validated, and all problems are
+// reported together.
+func LoadConfig(path string) (*Config, error) {
+	dotEnv, err := readDotEnv()
+	if err != nil {
+		return nil, err
 	}
 
-	if !loaded {
-		log.Printf("Warning: .env file not found in any of the expected locations")
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path = dotEnv["CONFIG_FILE"]
+	}
+	l, err := newLoader(path, dotEnv)
+	if err != nil {
+		return nil, err
 	}
 
 	config := &Config{
-		RPCURL:          getEnv("RPC_URL", "http://localhost:8545"),
-		PrivateKey:      getEnv("PRIVATE_KEY", ""),
-		ContractAddress: getEnv("CONTRACT_ADDRESS", ""),
-		ServerPort:      getEnv("SERVER_PORT", "8080"),
-		APIKey:          getEnv("API_KEY", ""),
+		File: path,
+
+		RPCURL:          l.getEnv("RPC_URL", "http://localhost:8545"),
+		Signer:          loadSigner(l, func(suffix string) string { return suffix }),
+		ContractAddress: l.getEnv("CONTRACT_ADDRESS", ""),
+		ServerPort:      l.getEnv("SERVER_PORT", "8080"),
+		APIKey:          l.getEnv("API_KEY", ""),
+
+		// Logging configuration
+		LogLevel:  l.getEnv("LOG_LEVEL", "info"),
+		LogFormat: l.getEnv("LOG_FORMAT", "text"),
+
+		// Tracing configuration
+		TracingExporter:    l.getEnv("TRACING_EXPORTER", "none"),
+		TracingFile:        l.getEnv("TRACING_FILE", "traces.jsonl"),
+		TracingServiceName: l.getEnv("TRACING_SERVICE_NAME", "oracle-client"),
+		TracingSampleRatio: l.getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+
+		// Cache configuration
+		CacheBackend:    l.getEnv("CACHE_BACKEND", "redis"),
+		CacheMaxEntries: l.getEnvAsInt("CACHE_MAX_ENTRIES", 1024),
+		CacheLatestTTL:  l.getEnvAsDuration("CACHE_LATEST_TTL", 10*time.Second),
+		CacheRoundTTL:   l.getEnvAsDuration("CACHE_ROUND_TTL", 10*time.Second),
+
+		// HTTP limits
+		RateLimitBackend:   l.getEnv("RATE_LIMIT_BACKEND", l.getEnv("CACHE_BACKEND", "redis")),
+		RateLimitRequests:  l.getEnvAsInt("RATE_LIMIT_REQUESTS", 10),
+		RateLimitWindow:    l.getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
+		CORSAllowedOrigins: splitList(l.getEnv("CORS_ALLOWED_ORIGINS", "*")),
 
 		// Redis configuration
-		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnvAsInt("REDIS_DB", 0),
+		RedisAddr:     l.getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: l.getEnv("REDIS_PASSWORD", ""),
+		RedisDB:       l.getEnvAsInt("REDIS_DB", 0),
 
 		// Postgres configuration
-		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
-		PostgresPort:     getEnv("POSTGRES_PORT", "5432"),
-		PostgresUser:     getEnv("POSTGRES_USER", "oracle"),
-		PostgresPassword: getEnv("POSTGRES_PASSWORD", "oracle"),
-		PostgresDB:       getEnv("POSTGRES_DB", "oracle_db"),
-	}
+		PostgresHost:     l.getEnv("POSTGRES_HOST", "localhost"),
+		PostgresPort:     l.getEnv("POSTGRES_PORT", "5432"),
+		PostgresUser:     l.getEnv("POSTGRES_USER", "oracle"),
+		PostgresPassword: l.getEnv("POSTGRES_PASSWORD", "oracle"),
+		PostgresDB:       l.getEnv("POSTGRES_DB", "oracle_db"),
 
-	if config.PrivateKey == "" {
-		return nil, fmt.Errorf("PRIVATE_KEY environment variable is required")
-	}
+		// Indexer configuration
+		IndexerEnabled:       l.getEnvAsBool("INDEXER_ENABLED", true),
+		IndexerStartBlock:    l.getEnvAsUint64("INDEXER_START_BLOCK", 0),
+		IndexerBatchSize:     l.getEnvAsUint64("INDEXER_BATCH_SIZE", 1000),
+		IndexerPollInterval:  l.getEnvAsDuration("INDEXER_POLL_INTERVAL", 5*time.Second),
+		IndexerConfirmations: l.getEnvAsUint64("INDEXER_CONFIRMATIONS", 12),
+
+		// Transaction tracking configuration
+		TxConfirmations: l.getEnvAsUint64("TX_CONFIRMATIONS", 1),
+		TxPollInterval:  l.getEnvAsDuration("TX_POLL_INTERVAL", 2*time.Second),
+		TxDropTimeout:   l.getEnvAsDuration("TX_DROP_TIMEOUT", 5*time.Minute),
+		TxWaitTimeout:   l.getEnvAsDuration("TX_WAIT_TIMEOUT", 60*time.Second),
+
+		// Transaction fee configuration
+		TxMaxFeeGwei:         l.getEnvAsUint64("TX_MAX_FEE_GWEI", 0),
+		TxMaxPriorityFeeGwei: l.getEnvAsUint64("TX_MAX_PRIORITY_FEE_GWEI", 0),
+		TxGasLimitMargin:     l.getEnvAsUint64("TX_GAS_LIMIT_MARGIN", 20),
+		TxBumpAfterBlocks:    l.getEnvAsUint64("TX_BUMP_AFTER_BLOCKS", 3),
+		TxBumpPercent:        l.getEnvAsUint64("TX_BUMP_PERCENT", 15),
+		TxBumpInterval:       l.getEnvAsDuration("TX_BUMP_INTERVAL", 15*time.Second),
+
+		// RPC circuit breaker configuration
+		RPCBreakerFailures:       l.getEnvAsInt("RPC_BREAKER_FAILURES", 5),
+		RPCBreakerOpenTimeout:    l.getEnvAsDuration("RPC_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+		RPCBreakerHalfOpenProbes: l.getEnvAsInt("RPC_BREAKER_HALF_OPEN_PROBES", 1),
+
+		// RPC provider pool configuration
+		RPCMaxBlockLag:         l.getEnvAsUint64("RPC_MAX_BLOCK_LAG", 5),
+		RPCHealthCheckInterval: l.getEnvAsDuration("RPC_HEALTH_CHECK_INTERVAL", 15*time.Second),
+		RPCReadQuorum:          l.getEnvAsInt("RPC_READ_QUORUM", 0),
 
-	if config.ContractAddress == "" {
-		return nil, fmt.Errorf("CONTRACT_ADDRESS environment variable is required")
+		// Retry policies
+		RetryReadAttempts:       l.getEnvAsInt("RETRY_READ_ATTEMPTS", 3),
+		RetryReadBaseDelay:      l.getEnvAsDuration("RETRY_READ_BASE_DELAY", 100*time.Millisecond),
+		RetryReadMaxDelay:       l.getEnvAsDuration("RETRY_READ_MAX_DELAY", 5*time.Second),
+		RetryReadAttemptTimeout: l.getEnvAsDuration("RETRY_READ_ATTEMPT_TIMEOUT", 5*time.Second),
+		RetryTxAttempts:         l.getEnvAsInt("RETRY_TX_ATTEMPTS", 3),
+		RetryTxBaseDelay:        l.getEnvAsDuration("RETRY_TX_BASE_DELAY", 500*time.Millisecond),
+		RetryTxMaxDelay:         l.getEnvAsDuration("RETRY_TX_MAX_DELAY", 5*time.Second),
+		RetryTxAttemptTimeout:   l.getEnvAsDuration("RETRY_TX_ATTEMPT_TIMEOUT", 0),
+
+		// Pusher configuration
+		PusherPollInterval:     l.getEnvAsDuration("PUSHER_POLL_INTERVAL", 10*time.Second),
+		PusherSourceTimeout:    l.getEnvAsDuration("PUSHER_SOURCE_TIMEOUT", 5*time.Second),
+		PusherMaxQuoteAge:      l.getEnvAsDuration("PUSHER_MAX_QUOTE_AGE", 5*time.Minute),
+		PusherOutlierMethod:    l.getEnv("PUSHER_OUTLIER_METHOD", "mad"),
+		PusherOutlierThreshold: l.getEnvAsFloat("PUSHER_OUTLIER_THRESHOLD", 3),
+		PusherOutlierMinBand:   l.getEnvAsFloat("PUSHER_OUTLIER_MIN_BAND", 0.1),
+
+		// Streaming configuration
+		HealthCheckInterval: l.getEnvAsDuration("HEALTH_CHECK_INTERVAL", 15*time.Second),
+		WSMaxTopics:         l.getEnvAsInt("WS_MAX_TOPICS", 32),
+		WSSendBuffer:        l.getEnvAsInt("WS_SEND_BUFFER", 256),
 	}
 
-	return config, nil
-}
+	loadRateLimits(l, config)
 
-// getEnv gets an environment variable with a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	chains, err := loadChains(l, config.RPCURL)
+	if err != nil {
+		return nil, err
 	}
-	return defaultValue
-}
+	config.Chains = chains
 
-// getEnvAsInt gets an environment variable as integer with a default value
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+	feeds, err := loadFeeds(l, config)
+	if err != nil {
+		return nil, err
 	}
-	return defaultValue
+	config.Feeds = feeds
+	config.Settings = l.settings
+
+	errs := append(l.errs, l.unknownKeys()...)
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return config, nil
 }
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultFeed is the name of the feed built from CONTRACT_ADDRESS when FEEDS is unset
+const DefaultFeed = "default"
+
+// DefaultChain is the name of the chain reached through RPC_URL
+const DefaultChain = "default"
+
+// FeedConfig describes one oracle feed served by this process
+type FeedConfig struct {
+	Name    string
+	Address string
+	Chain   string
+	Signer  SignerConfig // optional; feeds without a signer are read-only
+
+	// Automated pushing; disabled when PusherSources is empty
+	PusherSources   string // comma-separated source specs
+	PusherQuorum    int
+	PusherDeviation float64 // percent
+	PusherHeartbeat time.Duration
+
+	// MaxPriceAge is how old the latest round may be before it is stale;
+	// 0 disables the check
+	MaxPriceAge time.Duration
+}
+
+// SignerConfig selects how a feed's update transactions are signed: with a
+// raw PrivateKey, an encrypted Keystore unlocked from KeystorePasswordFile,
+// or a remote signer at URL holding the key for Address
+type SignerConfig struct {
+	PrivateKey           string
+	Keystore             string
+	KeystorePasswordFile string
+	URL                  string
+	Address              string
+}
+
+// Configured reports whether any signer is set
+func (s SignerConfig) Configured() bool {
+	return s.PrivateKey != "" || s.Keystore != "" || s.URL != ""
+}
+
+// loadSigner reads the signer settings named key("PRIVATE_KEY"),
+// key("KEYSTORE") and so on
+func loadSigner(l *loader, key func(suffix string) string) SignerConfig {
+	return SignerConfig{
+		PrivateKey:           l.getEnv(key("PRIVATE_KEY"), ""),
+		Keystore:             l.getEnv(key("KEYSTORE"), ""),
+		KeystorePasswordFile: l.getEnv(key("KEYSTORE_PASSWORD_FILE"), ""),
+		URL:                  l.getEnv(key("SIGNER_URL"), ""),
+		Address:              l.getEnv(key("SIGNER_ADDRESS"), ""),
+	}
+}
+
+// feedNamePattern restricts feed names to what can appear in a URL path segment
+var feedNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// loadChains reads CHAINS=name1,name2 and CHAIN_<NAME>_RPC_URL for each entry.
+// The default chain always points at RPC_URL. Each value may list several
+// endpoints, see rpc.ParseEndpoints.
+func loadChains(l *loader, defaultRPCURL string) (map[string]string, error) {
+	chains := map[string]string{DefaultChain: defaultRPCURL}
+
+	for _, name := range splitList(l.getEnv("CHAINS", "")) {
+		rpcURL := l.getEnv(envKey("CHAIN", name, "RPC_URL"), "")
+		if rpcURL == "" {
+			return nil, fmt.Errorf("%s is required for chain %q", envKey("CHAIN", name, "RPC_URL"), name)
+		}
+		chains[name] = rpcURL
+	}
+
+	return chains, nil
+}
+
+// loadFeeds reads FEEDS=name1,name2 and FEED_<NAME>_ADDRESS, FEED_<NAME>_CHAIN,
+// FEED_<NAME>_MAX_PRICE_AGE and the FEED_<NAME>_ signer settings for each
+// entry. Without FEEDS, a single feed named "default" is built from
+// CONTRACT_ADDRESS and the unprefixed settings.
+func loadFeeds(l *loader, cfg *Config) ([]FeedConfig, error) {
+	names := splitList(l.getEnv("FEEDS", ""))
+	if len(names) == 0 {
+		if !cfg.Signer.Configured() {
+			return nil, fmt.Errorf("PRIVATE_KEY, KEYSTORE or SIGNER_URL environment variable is required")
+		}
+		if cfg.ContractAddress == "" {
+			return nil, fmt.Errorf("CONTRACT_ADDRESS environment variable is required")
+		}
+
+		feed := FeedConfig{
+			Name:            DefaultFeed,
+			Address:         cfg.ContractAddress,
+			Chain:           DefaultChain,
+			Signer:          cfg.Signer,
+			PusherSources:   l.getEnv("PUSHER_SOURCES", l.getEnv("PUSHER_SOURCE", "")),
+			PusherQuorum:    l.getEnvAsInt("PUSHER_QUORUM", 1),
+			PusherDeviation: l.getEnvAsFloat("PUSHER_DEVIATION_PERCENT", 0.5),
+			PusherHeartbeat: l.getEnvAsDuration("PUSHER_HEARTBEAT", time.Hour),
+		}
+		feed.MaxPriceAge = l.getEnvAsDuration("MAX_PRICE_AGE", defaultMaxPriceAge(feed.PusherHeartbeat))
+		return []FeedConfig{feed}, nil
+	}
+
+	feeds := make([]FeedConfig, 0, len(names))
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		if !feedNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid feed name %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("feed %q is listed twice", name)
+		}
+		seen[name] = true
+
+		feed := FeedConfig{
+			Name:    name,
+			Address: l.getEnv(envKey("FEED", name, "ADDRESS"), ""),
+			Chain:   l.getEnv(envKey("FEED", name, "CHAIN"), DefaultChain),
+			Signer:  loadSigner(l, func(suffix string) string { return envKey("FEED", name, suffix) }),
+
+			PusherSources:   l.getEnv(envKey("FEED", name, "PUSHER_SOURCES"), l.getEnv(envKey("FEED", name, "PUSHER_SOURCE"), "")),
+			PusherQuorum:    l.getEnvAsInt(envKey("FEED", name, "PUSHER_QUORUM"), l.getEnvAsInt("PUSHER_QUORUM", 1)),
+			PusherDeviation: l.getEnvAsFloat(envKey("FEED", name, "PUSHER_DEVIATION_PERCENT"), l.getEnvAsFloat("PUSHER_DEVIATION_PERCENT", 0.5)),
+			PusherHeartbeat: l.getEnvAsDuration(envKey("FEED", name, "PUSHER_HEARTBEAT"), l.getEnvAsDuration("PUSHER_HEARTBEAT", time.Hour)),
+		}
+
+		feed.MaxPriceAge = l.getEnvAsDuration(envKey("FEED", name, "MAX_PRICE_AGE"), l.getEnvAsDuration("MAX_PRICE_AGE", defaultMaxPriceAge(feed.PusherHeartbeat)))
+
+		if feed.Address == "" {
+			return nil, fmt.Errorf("%s is required for feed %q", envKey("FEED", name, "ADDRESS"), name)
+		}
+		if _, ok := cfg.Chains[feed.Chain]; !ok {
+			return nil, fmt.Errorf("feed %q uses unknown chain %q", name, feed.Chain)
+		}
+		if feed.PusherSources != "" && !feed.Signer.Configured() {
+			return nil, fmt.Errorf("feed %q has a pusher source but no signer", name)
+		}
+
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, nil
+}
+
+// defaultMaxPriceAge allows a feed to miss one heartbeat before its price is
+// stale
+func defaultMaxPriceAge(heartbeat time.Duration) time.Duration {
+	return 2 * heartbeat
+}
+
+// envKey builds PREFIX_NAME_SUFFIX, upper-casing name and replacing anything
+// that is not a letter or digit with an underscore
+func envKey(prefix, name, suffix string) string {
+	return prefix + "_" + normalizeKey(name) + "_" + suffix
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
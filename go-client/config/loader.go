@@ -0,0 +1,359 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Setting sources, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceDotEnv  = "dotenv"
+	SourceEnv     = "env"
+)
+
+// Setting is the effective value of one configuration key
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Reloadable lists the keys a running server picks up again on SIGHUP; every
+// other key needs a restart
+var Reloadable = []string{
+	"LOG_LEVEL",
+	"RATE_LIMIT_REQUESTS",
+	"RATE_LIMIT_WINDOW",
+	"RATE_LIMIT_READ_REQUESTS",
+	"RATE_LIMIT_READ_WINDOW",
+	"RATE_LIMIT_UPDATE_REQUESTS",
+	"RATE_LIMIT_UPDATE_WINDOW",
+	"RATE_LIMIT_ADMIN_REQUESTS",
+	"RATE_LIMIT_ADMIN_WINDOW",
+	"RATE_LIMIT_ROUTES",
+	"RATE_LIMIT_IP_REQUESTS",
+	"RATE_LIMIT_IP_WINDOW",
+	"TRUSTED_PROXIES",
+	"CACHE_LATEST_TTL",
+	"CACHE_ROUND_TTL",
+	"CORS_ALLOWED_ORIGINS",
+}
+
+// loader resolves configuration keys from the environment, then the .env
+// file, then the config file, then defaults. It records every key it
+// resolves, and collects parse errors instead of falling back to defaults.
+type loader struct {
+	file     map[string]string
+	dotEnv   map[string]string
+	path     string
+	settings []Setting
+	seen     map[string]bool
+	errs     []error
+}
+
+// dotEnvFiles are the places a .env file is looked for, nearest first
+var dotEnvFiles = []string{".env", "../.env", "../../.env"}
+
+// readDotEnv reads the first .env file found. Its values are kept apart from
+// the process environment, so that a reload reads the file again rather than
+// seeing the values it exported the first time.
+func readDotEnv() (map[string]string, error) {
+	for _, path := range dotEnvFiles {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		values, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		slog.Info("Loaded .env file", "path", path)
+		return values, nil
+	}
+
+	slog.Warn(".env file not found in any of the expected locations")
+	return map[string]string{}, nil
+}
+
+// newLoader reads the YAML config file at path, if any. dotEnv holds the
+// values of the .env file.
+func newLoader(path string, dotEnv map[string]string) (*loader, error) {
+	l := &loader{file: make(map[string]string), dotEnv: dotEnv, path: path, seen: make(map[string]bool)}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if err := flatten("", root, l.file); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// flatten turns nested mappings into KEY_SUBKEY entries named like the
+// environment variables, so that
+//
+//	feed:
+//	  eth-usd:
+//	    address: "0x..."
+//
+// sets FEED_ETH_USD_ADDRESS. Lists become comma-separated values.
+func flatten(prefix string, node map[string]any, out map[string]string) error {
+	for name, value := range node {
+		key := normalizeKey(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case nil:
+		case map[string]any:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				switch item.(type) {
+				case map[string]any, []any:
+					return fmt.Errorf("%s: lists may only hold plain values", key)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// normalizeKey upper-cases name and replaces anything that is not a letter
+// or digit with an underscore
+func normalizeKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+}
+
+// lookup returns the effective value of key and records it
+func (l *loader) lookup(key, defaultValue string) string {
+	value, source := defaultValue, SourceDefault
+	if fileValue, ok := l.file[key]; ok {
+		value, source = fileValue, SourceFile
+	}
+	if dotEnvValue := l.dotEnv[key]; dotEnvValue != "" {
+		value, source = dotEnvValue, SourceDotEnv
+	}
+	if envValue := os.Getenv(key); envValue != "" {
+		value, source = envValue, SourceEnv
+	}
+
+	if !l.seen[key] {
+		l.seen[key] = true
+		l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+	}
+	return value
+}
+
+// unknownKeys reports file keys that no setting read
+func (l *loader) unknownKeys() []error {
+	var unknown []string
+	for key := range l.file {
+		if !l.seen[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	errs := make([]error, 0, len(unknown))
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting in %s", key, l.path))
+	}
+	return errs
+}
+
+// invalid records a value that failed to parse
+func (l *loader) invalid(key, value, kind string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: invalid %s %q", key, kind, value))
+}
+
+// getEnv gets a setting with a default value
+func (l *loader) getEnv(key, defaultValue string) string {
+	return l.lookup(key, defaultValue)
+}
+
+// getEnvAsInt gets a setting as integer with a default value
+func (l *loader) getEnvAsInt(key string, defaultValue int) int {
+	value := l.lookup(key, strconv.Itoa(defaultValue))
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, value, "integer")
+		return defaultValue
+	}
+	return intValue
+}
+
+// getEnvAsUint64 gets a setting as non-negative integer with a default value
+func (l *loader) getEnvAsUint64(key string, defaultValue uint64) uint64 {
+	value := l.lookup(key, strconv.FormatUint(defaultValue, 10))
+	uintValue, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		l.invalid(key, value, "non-negative integer")
+		return defaultValue
+	}
+	return uintValue
+}
+
+// getEnvAsFloat gets a setting as float with a default value
+func (l *loader) getEnvAsFloat(key string, defaultValue float64) float64 {
+	value := l.lookup(key, strconv.FormatFloat(defaultValue, 'g', -1, 64))
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, value, "number")
+		return defaultValue
+	}
+	return floatValue
+}
+
+// getEnvAsBool gets a setting as boolean with a default value
+func (l *loader) getEnvAsBool(key string, defaultValue bool) bool {
+	value := l.lookup(key, strconv.FormatBool(defaultValue))
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, value, "boolean")
+		return defaultValue
+	}
+	return boolValue
+}
+
+// getEnvAsDuration gets a setting as duration with a default value
+func (l *loader) getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	value := l.lookup(key, defaultValue.String())
+	durationValue, err := time.ParseDuration(value)
+	if err != nil {
+		l.invalid(key, value, "duration")
+		return defaultValue
+	}
+	if durationValue < 0 {
+		l.invalid(key, value, "negative duration")
+		return defaultValue
+	}
+	return durationValue
+}
+
+// Dump writes the effective configuration as YAML that can be loaded back as
+// a config file, with secrets and RPC URL paths redacted and each key's
+// source noted
+func (c *Config) Dump(w io.Writer) error {
+	for _, setting := range c.RedactedSettings() {
+		if _, err := fmt.Fprintf(w, "%s: %q # %s\n", setting.Key, setting.Value, setting.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedactedSettings returns Settings with secrets and RPC URL paths redacted
+func (c *Config) RedactedSettings() []Setting {
+	settings := make([]Setting, len(c.Settings))
+	for i, setting := range c.Settings {
+		setting.Value = redactSetting(setting.Key, setting.Value)
+		settings[i] = setting
+	}
+	return settings
+}
+
+// RestartRequired returns the keys whose value differs in next and that are
+// not Reloadable
+func (c *Config) RestartRequired(next *Config) []string {
+	current := make(map[string]string, len(c.Settings))
+	for _, setting := range c.Settings {
+		current[setting.Key] = setting.Value
+	}
+
+	var changed []string
+	for _, setting := range next.Settings {
+		value, ok := current[setting.Key]
+		if (!ok || value != setting.Value) && !isReloadable(setting.Key) {
+			changed = append(changed, setting.Key)
+		}
+		delete(current, setting.Key)
+	}
+	for key := range current {
+		if !isReloadable(key) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func isReloadable(key string) bool {
+	for _, reloadable := range Reloadable {
+		if key == reloadable {
+			return true
+		}
+	}
+	return false
+}
+
+// secretMarkers identify keys whose values are never printed
+var secretMarkers = []string{"PRIVATE_KEY", "PASSWORD", "API_KEY", "SECRET", "TOKEN"}
+
+// redactSetting hides secrets, and the credentials and paths of URLs, which
+// often carry provider API keys
+func redactSetting(key, value string) string {
+	if value == "" {
+		return value
+	}
+	for _, marker := range secretMarkers {
+		if strings.Contains(key, marker) {
+			return "<redacted>"
+		}
+	}
+	if !strings.Contains(value, "://") {
+		return value
+	}
+
+	entries := strings.Split(value, ",")
+	for i, entry := range entries {
+		spec, options, hasOptions := strings.Cut(strings.TrimSpace(entry), ";")
+		parsed, err := url.Parse(spec)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+
+		redacted := parsed.Scheme + "://" + parsed.Host
+		if parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+			redacted += "/<redacted>"
+		}
+		if hasOptions {
+			redacted += ";" + options
+		}
+		entries[i] = redacted
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/114windd/oracle-client/internal/apikeys"
+	"github.com/114windd/oracle-client/internal/ratelimit"
+)
+
+// rateLimitScopes are the API key scopes that may have their own limit
+var rateLimitScopes = []string{apikeys.ScopeRead, apikeys.ScopeUpdate, apikeys.ScopeAdmin}
+
+// loadRateLimits reads RATE_LIMIT_<SCOPE>_REQUESTS and RATE_LIMIT_<SCOPE>_WINDOW
+// for each scope, defaulting the window to cfg.RateLimitWindow, and the
+// RATE_LIMIT_ROUTES list. Scopes without requests use the default limit.
+// RATE_LIMIT_IP_REQUESTS and RATE_LIMIT_IP_WINDOW limit each IP before
+// authentication. TRUSTED_PROXIES lists the addresses, as IPs or CIDRs,
+// whose X-Forwarded-For and X-Real-IP headers name the client.
+func loadRateLimits(l *loader, cfg *Config) {
+	cfg.RateLimitScopes = make(map[string]ratelimit.Limit)
+	for _, scope := range rateLimitScopes {
+		key := "RATE_LIMIT_" + strings.ToUpper(scope)
+		limit := ratelimit.Limit{
+			Requests: l.getEnvAsInt(key+"_REQUESTS", 0),
+			Window:   l.getEnvAsDuration(key+"_WINDOW", cfg.RateLimitWindow),
+		}
+		if limit.Requests != 0 {
+			cfg.RateLimitScopes[scope] = limit
+		}
+	}
+
+	cfg.RateLimitIP = ratelimit.Limit{
+		Requests: l.getEnvAsInt("RATE_LIMIT_IP_REQUESTS", 300),
+		Window:   l.getEnvAsDuration("RATE_LIMIT_IP_WINDOW", cfg.RateLimitWindow),
+	}
+
+	for _, proxy := range splitList(l.getEnv("TRUSTED_PROXIES", "")) {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			l.invalid("TRUSTED_PROXIES", proxy, "IP or CIDR")
+			continue
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix)
+	}
+
+	routes, err := ratelimit.ParseRoutes(l.getEnv("RATE_LIMIT_ROUTES", ""))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err))
+	}
+	cfg.RateLimitRoutes = routes
+}
+
+// parsePrefix parses a CIDR, or a single IP as the prefix holding only it
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// validate checks values that parsed but are out of range or malformed
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	check(validatePort("SERVER_PORT", c.ServerPort))
+	check(validatePort("POSTGRES_PORT", c.PostgresPort))
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		check(fmt.Errorf("LOG_LEVEL: invalid level %q", c.LogLevel))
+	}
+	check(validateOneOf("LOG_FORMAT", c.LogFormat, "text", "json"))
+	check(validateOneOf("TRACING_EXPORTER", c.TracingExporter, "none", "stdout", "otlp-file", "otlp"))
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		check(fmt.Errorf("TRACING_SAMPLE_RATIO: must be between 0 and 1, got %g", c.TracingSampleRatio))
+	}
+	check(validateOneOf("CACHE_BACKEND", c.CacheBackend, "redis", "memory"))
+	check(validateOneOf("RATE_LIMIT_BACKEND", c.RateLimitBackend, "redis", "memory"))
+	check(validateOneOf("PUSHER_OUTLIER_METHOD", c.PusherOutlierMethod, "mad", "band"))
+	if c.PusherOutlierMinBand < 0 {
+		check(fmt.Errorf("PUSHER_OUTLIER_MIN_BAND: must not be negative, got %g", c.PusherOutlierMinBand))
+	}
+
+	check(validatePositive("CACHE_MAX_ENTRIES", c.CacheMaxEntries))
+	check(validatePositive("RATE_LIMIT_REQUESTS", c.RateLimitRequests))
+	for _, scope := range rateLimitScopes {
+		key := "RATE_LIMIT_" + strings.ToUpper(scope)
+		if limit, ok := c.RateLimitScopes[scope]; ok {
+			check(validatePositive(key+"_REQUESTS", limit.Requests))
+			if limit.Window == 0 {
+				check(fmt.Errorf("%s_WINDOW: must be greater than zero", key))
+			}
+		}
+	}
+	if c.RateLimitIP.Requests < 0 {
+		check(fmt.Errorf("RATE_LIMIT_IP_REQUESTS: must not be negative"))
+	} else if c.RateLimitIP.Requests > 0 && c.RateLimitIP.Window <= 0 {
+		check(fmt.Errorf("RATE_LIMIT_IP_WINDOW: must be greater than zero"))
+	}
+	check(validatePositive("WS_MAX_TOPICS", c.WSMaxTopics))
+	check(validatePositive("WS_SEND_BUFFER", c.WSSendBuffer))
+	check(validatePositive("RETRY_READ_ATTEMPTS", c.RetryReadAttempts))
+	check(validatePositive("RETRY_TX_ATTEMPTS", c.RetryTxAttempts))
+	if c.RPCBreakerFailures < 0 {
+		check(fmt.Errorf("RPC_BREAKER_FAILURES: must not be negative"))
+	}
+	if c.RPCReadQuorum < 0 {
+		check(fmt.Errorf("RPC_READ_QUORUM: must not be negative"))
+	}
+	if c.TxBumpAfterBlocks > 0 && c.TxBumpPercent < 10 {
+		check(fmt.Errorf("TX_BUMP_PERCENT: nodes reject replacements below 10 percent, got %d", c.TxBumpPercent))
+	}
+
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"RATE_LIMIT_WINDOW", c.RateLimitWindow},
+		{"INDEXER_POLL_INTERVAL", c.IndexerPollInterval},
+		{"TX_POLL_INTERVAL", c.TxPollInterval},
+		{"TX_DROP_TIMEOUT", c.TxDropTimeout},
+		{"TX_WAIT_TIMEOUT", c.TxWaitTimeout},
+		{"TX_BUMP_INTERVAL", c.TxBumpInterval},
+		{"RPC_BREAKER_OPEN_TIMEOUT", c.RPCBreakerOpenTimeout},
+		{"RPC_HEALTH_CHECK_INTERVAL", c.RPCHealthCheckInterval},
+		{"PUSHER_POLL_INTERVAL", c.PusherPollInterval},
+		{"PUSHER_SOURCE_TIMEOUT", c.PusherSourceTimeout},
+		{"HEALTH_CHECK_INTERVAL", c.HealthCheckInterval},
+	} {
+		if d.value == 0 {
+			check(fmt.Errorf("%s: must be greater than zero", d.key))
+		}
+	}
+
+	for _, origin := range c.CORSAllowedOrigins {
+		check(validateOrigin(origin))
+	}
+
+	for name, spec := range c.Chains {
+		key := "RPC_URL"
+		if name != DefaultChain {
+			key = envKey("CHAIN", name, "RPC_URL")
+		}
+		check(validateRPCURLs(key, spec))
+	}
+
+	errs = append(errs, validateSigner(func(suffix string) string { return suffix }, c.Signer)...)
+	if c.ContractAddress != "" {
+		check(validateAddress("CONTRACT_ADDRESS", c.ContractAddress))
+	}
+	for _, feed := range c.Feeds {
+		if feed.Name == DefaultFeed && feed.Address == c.ContractAddress {
+			// Built from CONTRACT_ADDRESS and the unprefixed signer, checked above
+			continue
+		}
+		check(validateAddress(envKey("FEED", feed.Name, "ADDRESS"), feed.Address))
+		errs = append(errs, validateSigner(func(suffix string) string { return envKey("FEED", feed.Name, suffix) }, feed.Signer)...)
+	}
+
+	return errs
+}
+
+// validateSigner checks that at most one kind of signer is configured and
+// that it is complete; key names each setting for error messages
+func validateSigner(key func(suffix string) string, s SignerConfig) []error {
+	var errs []error
+
+	kinds := 0
+	for _, value := range []string{s.PrivateKey, s.Keystore, s.URL} {
+		if value != "" {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		errs = append(errs, fmt.Errorf("%s, %s and %s: set only one", key("PRIVATE_KEY"), key("KEYSTORE"), key("SIGNER_URL")))
+	}
+
+	if s.PrivateKey != "" {
+		if err := validatePrivateKey(key("PRIVATE_KEY"), s.PrivateKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.Keystore != "" && s.KeystorePasswordFile == "" {
+		errs = append(errs, fmt.Errorf("%s: required with %s", key("KEYSTORE_PASSWORD_FILE"), key("KEYSTORE")))
+	}
+	if s.URL != "" {
+		if s.Address == "" {
+			errs = append(errs, fmt.Errorf("%s: required with %s", key("SIGNER_ADDRESS"), key("SIGNER_URL")))
+		} else if err := validateAddress(key("SIGNER_ADDRESS"), s.Address); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validatePort accepts TCP ports 1 to 65535
+func validatePort(key, value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("%s: invalid port %q", key, value)
+	}
+	return nil
+}
+
+func validateOneOf(key, value string, allowed ...string) error {
+	for _, option := range allowed {
+		if strings.EqualFold(value, option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+}
+
+func validatePositive(key string, value int) error {
+	if value <= 0 {
+		return fmt.Errorf("%s: must be greater than zero, got %d", key, value)
+	}
+	return nil
+}
+
+// validateAddress accepts 0x-prefixed 20-byte hex addresses. Mixed-case
+// addresses must carry a valid EIP-55 checksum.
+func validateAddress(key, value string) error {
+	if !strings.HasPrefix(value, "0x") || !common.IsHexAddress(value) {
+		return fmt.Errorf("%s: invalid address %q", key, value)
+	}
+	hex := value[2:]
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && common.HexToAddress(value).Hex() != value {
+		return fmt.Errorf("%s: address %q has an invalid checksum", key, value)
+	}
+	return nil
+}
+
+// validatePrivateKey accepts a 32-byte hex secp256k1 key, with or without
+// 0x, without ever echoing it
+func validatePrivateKey(key, value string) error {
+	hex := strings.TrimPrefix(value, "0x")
+	if len(hex) != 64 {
+		return fmt.Errorf("%s: private key must be 64 hex characters", key)
+	}
+	if _, err := crypto.HexToECDSA(hex); err != nil {
+		return fmt.Errorf("%s: invalid private key", key)
+	}
+	return nil
+}
+
+// validateOrigin accepts "*" or a scheme://host[:port] origin
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") {
+		return fmt.Errorf("CORS_ALLOWED_ORIGINS: invalid origin %q", origin)
+	}
+	return nil
+}
+
+// validateRPCURLs checks an RPC endpoint list as the provider pool will
+// parse it, so that endpoints the pool rejects fail at startup
+func validateRPCURLs(key, spec string) error {
+	endpoints, err := rpc.ParseEndpoints(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	for _, endpoint := range endpoints {
+		if !rpc.IsHTTP(endpoint.URL) {
+			return fmt.Errorf("%s: RPC endpoint %q is not HTTP(S); only HTTP endpoints can fail over and pass through the circuit breaker", key, endpoint.Name)
+		}
+		if parsed, err := url.Parse(endpoint.URL); err != nil || parsed.Host == "" {
+			return fmt.Errorf("%s: RPC endpoint %q has no host", key, endpoint.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import "testing"
+
+func TestValidateRPCURLs(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"http://localhost:8545", false},
+		{"https://a.example/key;name=main,https://b.example;priority=5", false},
+		{"", true},
+		{"ws://localhost:8546", true},
+		{"wss://a.example,wss://b.example", true},
+		{"https://a.example,ws://b.example", true},
+		{"/tmp/geth.ipc", true},
+		{"https://a.example;weight=2", true},
+		{"http://", true},
+	}
+	for _, tt := range tests {
+		err := validateRPCURLs("RPC_URL", tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateRPCURLs(%q) = %v, want error %v", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false},
+		{"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", false},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", true},
+		{"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", true},
+		{"0xzzaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+	}
+	for _, tt := range tests {
+		err := validateAddress("CONTRACT_ADDRESS", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateAddress(%q) = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"8080", false},
+		{"1", false},
+		{"65535", false},
+		{"0", true},
+		{"65536", true},
+		{"-1", true},
+		{"http", true},
+	}
+	for _, tt := range tests {
+		err := validatePort("SERVER_PORT", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePort(%q) = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
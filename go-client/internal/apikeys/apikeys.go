@@ -0,0 +1,86 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scopes
+const (
+	ScopeRead   = "read"   // read prices, rounds, transactions and streams
+	ScopeUpdate = "update" // submit price updates
+	ScopeAdmin  = "admin"  // manage API keys; implies every other scope
+)
+
+// prefix starts every key this service issues
+const prefix = "ok"
+
+// Generate creates a new key. The token is handed to the client once; only
+// the ID and the hash of its secret are stored.
+func Generate() (id, token, secretHash string, err error) {
+	var idBytes [8]byte
+	var secret [32]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return "", "", "", err
+	}
+	if _, err := rand.Read(secret[:]); err != nil {
+		return "", "", "", err
+	}
+
+	id = hex.EncodeToString(idBytes[:])
+	secretHex := hex.EncodeToString(secret[:])
+	return id, prefix + "_" + id + "_" + secretHex, Hash(secretHex), nil
+}
+
+// Parse splits a token into its key ID and secret
+func Parse(token string) (id, secret string, ok bool) {
+	parts := strings.Split(token, "_")
+	if len(parts) != 3 || parts[0] != prefix || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// Hash returns the stored form of a secret. Secrets are 256 random bits, so
+// a plain SHA-256 cannot be brute-forced and needs no salt or stretching.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports, in constant time, whether secret matches secretHash
+func Verify(secret, secretHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(secretHash)) == 1
+}
+
+// ParseScopes validates scopes and returns them sorted, without duplicates
+func ParseScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	var parsed []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch scope {
+		case ScopeRead, ScopeUpdate, ScopeAdmin:
+		default:
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !slices.Contains(parsed, scope) {
+			parsed = append(parsed, scope)
+		}
+	}
+	slices.Sort(parsed)
+	return parsed, nil
+}
+
+// HasScope reports whether granted allows required
+func HasScope(granted []string, required string) bool {
+	return slices.Contains(granted, required) || slices.Contains(granted, ScopeAdmin)
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Backend names accepted by New
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// RoundData represents cached round data
+type RoundData struct {
+	RoundID         uint64 `json:"roundId"`
+	Answer          string `json:"answer"`
+	StartedAt       int64  `json:"startedAt"`
+	UpdatedAt       int64  `json:"updatedAt"`
+	AnsweredInRound uint64 `json:"answeredInRound"`
+}
+
+// Cache is implemented by every cache backend. Get returns nil, nil on a miss.
+type Cache interface {
+	Get(ctx context.Context, key string) (*RoundData, error)
+	Set(ctx context.Context, key string, data *RoundData, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// Options selects and configures a cache backend
+type Options struct {
+	Backend string
+
+	// Redis backend
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	// Memory backend
+	MaxEntries int
+}
+
+// New creates the cache backend selected in opts, instrumented with tracing,
+// metrics and failure logging
+func New(opts Options) (Cache, error) {
+	switch opts.Backend {
+	case BackendRedis, "":
+		return Instrument(NewRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB)), nil
+	case BackendMemory:
+		return Instrument(NewMemory(opts.MaxEntries)), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", opts.Backend)
+	}
+}
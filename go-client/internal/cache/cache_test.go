@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// backend is a cache under test and a way to let its TTLs run out
+type backend struct {
+	name    string
+	cache   Cache
+	advance func(time.Duration)
+}
+
+func backends(t *testing.T) []backend {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	redisCache := NewRedis(server.Addr(), "", 0)
+	t.Cleanup(func() { redisCache.Close() })
+
+	return []backend{
+		{"memory", NewMemory(16), func(d time.Duration) { time.Sleep(d) }},
+		{"redis", redisCache, server.FastForward},
+	}
+}
+
+func round(id uint64) *RoundData {
+	return &RoundData{RoundID: id, Answer: "200000000000", StartedAt: 1700000000, UpdatedAt: 1700000000, AnsweredInRound: id}
+}
+
+// TestBackends checks that every backend behaves the same behind the Cache
+// interface
+func TestBackends(t *testing.T) {
+	ctx := context.Background()
+
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			if err := b.cache.Ping(ctx); err != nil {
+				t.Fatalf("Ping: %v", err)
+			}
+
+			// A miss is nil, nil
+			data, err := b.cache.Get(ctx, "feed:eth-usd:latest")
+			if data != nil || err != nil {
+				t.Fatalf("Get on a miss = %v, %v, want nil, nil", data, err)
+			}
+
+			// Values round-trip and overwrite
+			if err := b.cache.Set(ctx, "feed:eth-usd:latest", round(1), 0); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if err := b.cache.Set(ctx, "feed:eth-usd:latest", round(2), 0); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			data, err = b.cache.Get(ctx, "feed:eth-usd:latest")
+			if err != nil || data == nil || *data != *round(2) {
+				t.Fatalf("Get = %+v, %v, want %+v", data, err, round(2))
+			}
+
+			// Del removes the key, and deleting a missing key is not an error
+			if err := b.cache.Del(ctx, "feed:eth-usd:latest"); err != nil {
+				t.Fatalf("Del: %v", err)
+			}
+			if data, _ := b.cache.Get(ctx, "feed:eth-usd:latest"); data != nil {
+				t.Errorf("Get after Del = %+v, want a miss", data)
+			}
+			if err := b.cache.Del(ctx, "feed:eth-usd:latest"); err != nil {
+				t.Errorf("Del of a missing key: %v", err)
+			}
+
+			// Entries expire after their TTL; a zero TTL never expires
+			if err := b.cache.Set(ctx, "feed:eth-usd:round:1", round(1), 200*time.Millisecond); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if err := b.cache.Set(ctx, "feed:eth-usd:round:2", round(2), 0); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if data, _ := b.cache.Get(ctx, "feed:eth-usd:round:1"); data == nil {
+				t.Error("entry expired before its TTL")
+			}
+			b.advance(250 * time.Millisecond)
+			if data, _ := b.cache.Get(ctx, "feed:eth-usd:round:1"); data != nil {
+				t.Errorf("Get after the TTL = %+v, want a miss", data)
+			}
+			if data, _ := b.cache.Get(ctx, "feed:eth-usd:round:2"); data == nil {
+				t.Error("entry without a TTL expired")
+			}
+
+			// A write with a new TTL replaces the old one
+			if err := b.cache.Set(ctx, "feed:eth-usd:round:2", round(2), 200*time.Millisecond); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			b.advance(250 * time.Millisecond)
+			if data, _ := b.cache.Get(ctx, "feed:eth-usd:round:2"); data != nil {
+				t.Errorf("Get after the new TTL = %+v, want a miss", data)
+			}
+		})
+	}
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	c := NewRedis(server.Addr(), "", 0)
+	defer c.Close()
+	server.Close()
+
+	ctx := context.Background()
+	if err := c.Ping(ctx); err == nil {
+		t.Error("Ping succeeded without a server")
+	}
+	if data, err := c.Get(ctx, "feed:eth-usd:latest"); err == nil {
+		t.Errorf("Get without a server = %+v, nil, want an error, not a miss", data)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, backend := range []string{"", BackendRedis, BackendMemory} {
+		if _, err := New(Options{Backend: backend}); err != nil {
+			t.Errorf("New(%q): %v", backend, err)
+		}
+	}
+	if _, err := New(Options{Backend: "memcached"}); err == nil {
+		t.Error("New accepted an unknown backend")
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// InstrumentedCache traces every operation of another cache, counts lookups
+// and write failures per key class, and logs every failure
+type InstrumentedCache struct {
+	Cache
+}
+
+// Instrument wraps c with tracing, metrics and failure logging
+func Instrument(c Cache) *InstrumentedCache {
+	return &InstrumentedCache{Cache: c}
+}
+
+// Get counts the lookup as a hit, miss or error
+func (c *InstrumentedCache) Get(ctx context.Context, key string) (*RoundData, error) {
+	ctx, span := tracing.Start(ctx, "cache.get", attribute.String("cache.key", key))
+	data, err := c.Cache.Get(ctx, key)
+	defer tracing.End(span, err)
+
+	result := "hit"
+	switch {
+	case err != nil:
+		result = "error"
+		slog.WarnContext(ctx, "cache get failed", "key", key, "err", err)
+	case data == nil:
+		result = "miss"
+	}
+	metrics.CacheRequests.WithLabelValues(keyClass(key), result).Inc()
+	span.SetAttributes(attribute.String("cache.result", result))
+
+	return data, err
+}
+
+// Set counts and logs failed writes
+func (c *InstrumentedCache) Set(ctx context.Context, key string, data *RoundData, ttl time.Duration) error {
+	ctx, span := tracing.Start(ctx, "cache.set", attribute.String("cache.key", key))
+	err := c.Cache.Set(ctx, key, data, ttl)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.CacheErrors.WithLabelValues(keyClass(key), "set").Inc()
+		slog.WarnContext(ctx, "cache set failed", "key", key, "err", err)
+	}
+	return err
+}
+
+// Del counts and logs failed deletes
+func (c *InstrumentedCache) Del(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "cache.del", attribute.String("cache.key", key))
+	err := c.Cache.Del(ctx, key)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.CacheErrors.WithLabelValues(keyClass(key), "del").Inc()
+		slog.WarnContext(ctx, "cache delete failed", "key", key, "err", err)
+	}
+	return err
+}
+
+// keyClass reduces a key to a bounded label: "feed:<name>:round:<id>" becomes
+// "round" and "feed:<name>:latest" becomes "latest"
+func keyClass(key string) string {
+	parts := strings.SplitN(key, ":", 4)
+	if parts[0] == "feed" && len(parts) >= 3 {
+		return parts[2]
+	}
+	return parts[0]
+}
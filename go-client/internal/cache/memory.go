@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultMaxEntries is used when NewMemory is given a non-positive size
+const defaultMaxEntries = 1024
+
+// MemoryCache is an in-process LRU cache with per-entry TTLs
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	data      RoundData
+	expiresAt time.Time
+}
+
+// NewMemory creates a new in-process LRU cache holding at most maxEntries keys
+func NewMemory(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get retrieves round data, treating expired entries as misses
+func (c *MemoryCache) Get(ctx context.Context, key string) (*RoundData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.removeElement(el)
+		return nil, nil
+	}
+
+	c.ll.MoveToFront(el)
+	data := entry.data
+	return &data, nil
+}
+
+// Set stores round data, evicting the least recently used entry when full.
+// A zero TTL means the entry never expires.
+func (c *MemoryCache) Set(ctx context.Context, key string, data *RoundData, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.data = *data
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, data: *data, expiresAt: expiresAt})
+
+	for c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+	return nil
+}
+
+// Del removes a key from the cache
+func (c *MemoryCache) Del(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	return nil
+}
+
+// Ping always succeeds for the in-process cache
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close drops all entries
+func (c *MemoryCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	return nil
+}
+
+func (c *MemoryCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
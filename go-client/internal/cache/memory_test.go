@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// cached returns the keys among keys that c holds
+func cached(c *MemoryCache, keys ...string) []string {
+	var hits []string
+	for _, key := range keys {
+		if data, _ := c.Get(context.Background(), key); data != nil {
+			hits = append(hits, key)
+		}
+	}
+	return hits
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(3)
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(ctx, key, round(1), 0)
+	}
+	// Reading a and rewriting b makes c the least recently used
+	c.Get(ctx, "a")
+	c.Set(ctx, "b", round(2), 0)
+
+	c.Set(ctx, "d", round(1), 0)
+	if got := fmt.Sprint(cached(c, "a", "b", "c", "d")); got != "[a b d]" {
+		t.Errorf("after evicting once, cache holds %s, want [a b d]", got)
+	}
+
+	// The lookups above moved a, b and d to the front in that order
+	c.Set(ctx, "e", round(1), 0)
+	c.Set(ctx, "f", round(1), 0)
+	if got := fmt.Sprint(cached(c, "a", "b", "c", "d", "e", "f")); got != "[d e f]" {
+		t.Errorf("after evicting twice more, cache holds %s, want [d e f]", got)
+	}
+	if n := c.ll.Len(); n != 3 || len(c.items) != 3 {
+		t.Errorf("list holds %d entries and map %d, want 3", n, len(c.items))
+	}
+}
+
+func TestMemoryExpiredEntriesAreDropped(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(3)
+
+	c.Set(ctx, "a", round(1), time.Millisecond)
+	c.Set(ctx, "b", round(1), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if data, _ := c.Get(ctx, "a"); data != nil {
+		t.Errorf("Get after the TTL = %+v, want a miss", data)
+	}
+	if _, ok := c.items["a"]; ok || c.ll.Len() != 1 {
+		t.Error("expired entry still held after a lookup")
+	}
+}
+
+func TestMemoryDel(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(2)
+
+	c.Set(ctx, "a", round(1), 0)
+	c.Set(ctx, "b", round(1), 0)
+	c.Del(ctx, "a")
+
+	// The freed slot is reused without evicting b
+	c.Set(ctx, "c", round(1), 0)
+	if got := fmt.Sprint(cached(c, "a", "b", "c")); got != "[b c]" {
+		t.Errorf("cache holds %s, want [b c]", got)
+	}
+}
+
+func TestMemoryStoresCopies(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(1)
+
+	data := round(1)
+	c.Set(ctx, "a", data, 0)
+	data.Answer = "0"
+
+	got, _ := c.Get(ctx, "a")
+	got.RoundID = 99
+	if again, _ := c.Get(ctx, "a"); *again != *round(1) {
+		t.Errorf("cached entry = %+v, want it unaffected by callers", again)
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores round data in Redis as JSON
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedis creates a new Redis-backed cache
+func NewRedis(addr, password string, db int) *RedisCache {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	return &RedisCache{client: client}
+}
+
+// Get retrieves round data from Redis
+func (c *RedisCache) Get(ctx context.Context, key string) (*RoundData, error) {
+	raw, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var data RoundData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Set stores round data in Redis with the given TTL
+func (c *RedisCache) Set(ctx context.Context, key string, data *RoundData, ttl time.Duration) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key, raw, ttl).Err()
+}
+
+// Del removes a key from Redis
+func (c *RedisCache) Del(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key).Err()
+}
+
+// Ping tests the Redis connection
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis connection
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
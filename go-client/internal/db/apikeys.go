@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is an API key. Only a hash of its secret is stored.
+type APIKey struct {
+	ID         string `gorm:"primaryKey"`
+	Label      string `gorm:"not null"`
+	Scopes     string `gorm:"not null"` // comma-separated
+	SecretHash string `gorm:"not null"`
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	CreatedBy  string // ID of the key that created this one
+	RevokedBy  string
+	CreatedAt  time.Time
+}
+
+// CreateAPIKey inserts a new API key
+func (d *DB) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	return d.db.WithContext(ctx).Create(key).Error
+}
+
+// GetAPIKey retrieves an API key by ID, including revoked and expired keys
+func (d *DB) GetAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys retrieves every API key, newest first
+func (d *DB) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	var keys []*APIKey
+	err := d.db.WithContext(ctx).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey marks a key as revoked by revokedBy. It reports false if no
+// unrevoked key has that ID.
+func (d *DB) RevokeAPIKey(ctx context.Context, id, revokedBy string) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoked_by": revokedBy})
+	return result.RowsAffected > 0, result.Error
+}
@@ -2,21 +2,35 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// OracleRound represents oracle round data
+// OracleRound represents oracle round data. The (feed, round_id) primary key
+// serves lookups and ranges by round ID, and idx_oracle_rounds_feed_updated_at
+// those by time.
 type OracleRound struct {
-	RoundID         uint64    `gorm:"primaryKey"`
+	Feed            string    `gorm:"primaryKey;default:default;index:idx_oracle_rounds_feed_updated_at,priority:1"`
+	RoundID         uint64    `gorm:"primaryKey;autoIncrement:false"`
 	Answer          string    `gorm:"not null"`
 	StartedAt       time.Time `gorm:"not null"`
-	UpdatedAt       time.Time `gorm:"not null"`
+	UpdatedAt       time.Time `gorm:"not null;autoUpdateTime:false;index:idx_oracle_rounds_feed_updated_at,priority:2"`
 	AnsweredInRound uint64    `gorm:"not null"`
 	TxHash          string
+	BlockNumber     uint64
+	LogIndex        uint
+}
+
+// IndexerCheckpoint records the last block fully processed by an indexer
+type IndexerCheckpoint struct {
+	Name        string `gorm:"primaryKey"`
+	BlockNumber uint64 `gorm:"not null"`
+	UpdatedAt   time.Time
 }
 
 // DB wraps GORM database
@@ -34,14 +48,37 @@ func New(host, user, password, dbname, port string) (*DB, error) {
 		return nil, err
 	}
 
+	if err := registerInstrumentation(db); err != nil {
+		return nil, err
+	}
+
+	// Tables created before feeds were introduced are keyed by round_id alone
+	legacyRounds := db.Migrator().HasTable(&OracleRound{}) && !db.Migrator().HasColumn(&OracleRound{}, "feed")
+
 	// Auto-migrate
-	if err := db.AutoMigrate(&OracleRound{}); err != nil {
+	if err := db.AutoMigrate(&OracleRound{}, &IndexerCheckpoint{}, &Transaction{}, &APIKey{}); err != nil {
 		return nil, err
 	}
 
+	if legacyRounds {
+		if err := migrateRoundKey(db); err != nil {
+			return nil, err
+		}
+	}
+
 	return &DB{db: db}, nil
 }
 
+// migrateRoundKey rebuilds the oracle_rounds primary key as (feed, round_id)
+func migrateRoundKey(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE oracle_rounds DROP CONSTRAINT IF EXISTS oracle_rounds_pkey").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE oracle_rounds ADD PRIMARY KEY (feed, round_id)").Error
+	})
+}
+
 // Close closes the database connection
 func (d *DB) Close() error {
 	sqlDB, err := d.db.DB()
@@ -65,10 +102,10 @@ func (d *DB) Save(ctx context.Context, round *OracleRound) error {
 	return d.db.WithContext(ctx).Create(round).Error
 }
 
-// GetByRoundID retrieves round data by round ID
-func (d *DB) GetByRoundID(ctx context.Context, roundId uint64) (*OracleRound, error) {
+// GetByRoundID retrieves round data for a feed by round ID
+func (d *DB) GetByRoundID(ctx context.Context, feed string, roundId uint64) (*OracleRound, error) {
 	var round OracleRound
-	err := d.db.WithContext(ctx).Where("round_id = ?", roundId).First(&round).Error
+	err := d.db.WithContext(ctx).Where("feed = ? AND round_id = ?", feed, roundId).First(&round).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -78,10 +115,10 @@ func (d *DB) GetByRoundID(ctx context.Context, roundId uint64) (*OracleRound, er
 	return &round, nil
 }
 
-// GetLatest retrieves the latest round data
-func (d *DB) GetLatest(ctx context.Context) (*OracleRound, error) {
+// GetLatest retrieves the latest round data for a feed
+func (d *DB) GetLatest(ctx context.Context, feed string) (*OracleRound, error) {
 	var round OracleRound
-	err := d.db.WithContext(ctx).Order("round_id DESC").First(&round).Error
+	err := d.db.WithContext(ctx).Where("feed = ?", feed).Order("round_id DESC").First(&round).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -90,3 +127,139 @@ func (d *DB) GetLatest(ctx context.Context) (*OracleRound, error) {
 	}
 	return &round, nil
 }
+
+// GetRoundsAfter retrieves up to limit rounds of a feed with a round ID
+// greater than roundId, in ascending order
+func (d *DB) GetRoundsAfter(ctx context.Context, feed string, roundId uint64, limit int) ([]*OracleRound, error) {
+	var rounds []*OracleRound
+	err := d.db.WithContext(ctx).
+		Where("feed = ? AND round_id > ?", feed, roundId).
+		Order("round_id ASC").
+		Limit(limit).
+		Find(&rounds).Error
+	if err != nil {
+		return nil, err
+	}
+	return rounds, nil
+}
+
+// GetRoundRange retrieves the stored rounds of a feed with round IDs from
+// fromRound to toRound inclusive, in ascending order
+func (d *DB) GetRoundRange(ctx context.Context, feed string, fromRound, toRound uint64) ([]*OracleRound, error) {
+	var rounds []*OracleRound
+	err := d.db.WithContext(ctx).
+		Where("feed = ? AND round_id BETWEEN ? AND ?", feed, fromRound, toRound).
+		Order("round_id ASC").
+		Find(&rounds).Error
+	if err != nil {
+		return nil, err
+	}
+	return rounds, nil
+}
+
+// GetRoundsBetween retrieves the rounds of a feed updated at or after from
+// and before to, in ascending order
+func (d *DB) GetRoundsBetween(ctx context.Context, feed string, from, to time.Time) ([]*OracleRound, error) {
+	var rounds []*OracleRound
+	err := d.db.WithContext(ctx).
+		Where("feed = ? AND updated_at >= ? AND updated_at < ?", feed, from, to).
+		Order("updated_at ASC, round_id ASC").
+		Find(&rounds).Error
+	if err != nil {
+		return nil, err
+	}
+	return rounds, nil
+}
+
+// GetRoundBefore retrieves the last round of a feed updated before t, or nil
+// if there is none
+func (d *DB) GetRoundBefore(ctx context.Context, feed string, t time.Time) (*OracleRound, error) {
+	var round OracleRound
+	err := d.db.WithContext(ctx).
+		Where("feed = ? AND updated_at < ?", feed, t).
+		Order("updated_at DESC, round_id DESC").
+		First(&round).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &round, nil
+}
+
+// LastRoundBefore returns the highest stored round ID of a feed updated
+// before t. ok is false if there is none.
+func (d *DB) LastRoundBefore(ctx context.Context, feed string, t time.Time) (roundId uint64, ok bool, err error) {
+	return d.roundBound(ctx, "MAX(round_id)", "feed = ? AND updated_at < ?", feed, t)
+}
+
+// FirstRoundAfter returns the lowest stored round ID of a feed updated after
+// t. ok is false if there is none.
+func (d *DB) FirstRoundAfter(ctx context.Context, feed string, t time.Time) (roundId uint64, ok bool, err error) {
+	return d.roundBound(ctx, "MIN(round_id)", "feed = ? AND updated_at > ?", feed, t)
+}
+
+// FirstRoundSince returns the lowest stored round ID of a feed updated at or
+// after t. ok is false if there is none.
+func (d *DB) FirstRoundSince(ctx context.Context, feed string, t time.Time) (roundId uint64, ok bool, err error) {
+	return d.roundBound(ctx, "MIN(round_id)", "feed = ? AND updated_at >= ?", feed, t)
+}
+
+func (d *DB) roundBound(ctx context.Context, aggregate, query string, args ...any) (uint64, bool, error) {
+	var roundId sql.NullInt64
+	err := d.db.WithContext(ctx).Model(&OracleRound{}).Select(aggregate).Where(query, args...).Row().Scan(&roundId)
+	if err != nil || !roundId.Valid {
+		return 0, false, err
+	}
+	return uint64(roundId.Int64), true, nil
+}
+
+// SaveRounds stores rounds read from chain, keeping rows already stored
+func (d *DB) SaveRounds(ctx context.Context, rounds []*OracleRound) error {
+	if len(rounds) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rounds).Error
+}
+
+// SaveIndexedRounds upserts rounds read from chain logs and advances the
+// named checkpoint to blockNumber in a single transaction
+func (d *DB) SaveIndexedRounds(ctx context.Context, name string, rounds []*OracleRound, blockNumber uint64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(rounds) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "feed"}, {Name: "round_id"}},
+				UpdateAll: true,
+			}).Create(rounds).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(&IndexerCheckpoint{Name: name, BlockNumber: blockNumber}).Error
+	})
+}
+
+// DeleteIndexedRound deletes a round stored from the log at blockNumber and
+// logIndex of txHash, after a reorg removed that log. A row stored since from
+// another log is kept.
+func (d *DB) DeleteIndexedRound(ctx context.Context, feed string, roundId uint64, txHash string, blockNumber uint64, logIndex uint) (bool, error) {
+	result := d.db.WithContext(ctx).
+		Where("feed = ? AND round_id = ? AND tx_hash = ? AND block_number = ? AND log_index = ?", feed, roundId, txHash, blockNumber, logIndex).
+		Delete(&OracleRound{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetCheckpoint retrieves the named checkpoint. ok is false if none was stored yet.
+func (d *DB) GetCheckpoint(ctx context.Context, name string) (blockNumber uint64, ok bool, err error) {
+	var checkpoint IndexerCheckpoint
+	err = d.db.WithContext(ctx).Where("name = ?", name).First(&checkpoint).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return checkpoint.BlockNumber, true, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	metricsStartKey = "metrics:start"
+	tracingSpanKey  = "tracing:span"
+)
+
+// registerInstrumentation traces and times every GORM statement, and counts
+// and logs its failures
+func registerInstrumentation(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("instrument:before_create", start("create")),
+		cb.Create().After("*").Register("instrument:after_create", observe("create")),
+		cb.Query().Before("*").Register("instrument:before_query", start("query")),
+		cb.Query().After("*").Register("instrument:after_query", observe("query")),
+		cb.Update().Before("*").Register("instrument:before_update", start("update")),
+		cb.Update().After("*").Register("instrument:after_update", observe("update")),
+		cb.Delete().Before("*").Register("instrument:before_delete", start("delete")),
+		cb.Delete().After("*").Register("instrument:after_delete", observe("delete")),
+		cb.Row().Before("*").Register("instrument:before_row", start("row")),
+		cb.Row().After("*").Register("instrument:after_row", observe("row")),
+		cb.Raw().Before("*").Register("instrument:before_raw", start("raw")),
+		cb.Raw().After("*").Register("instrument:after_raw", observe("raw")),
+	)
+}
+
+// start opens a span for the statement and records its start time
+func start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := tracing.Start(db.Statement.Context, "db."+operation)
+		db.Statement.Context = ctx
+		db.InstanceSet(tracingSpanKey, span)
+		db.InstanceSet(metricsStartKey, time.Now())
+	}
+}
+
+// observe ends the statement's span and records its latency and failure.
+// Missing records are not failures.
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		began, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		var err error
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			err = db.Error
+		}
+
+		if value, ok := db.InstanceGet(tracingSpanKey); ok {
+			span := value.(trace.Span)
+			span.SetAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.sql.table", table),
+				attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+			)
+			tracing.End(span, err)
+		}
+
+		metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(began.(time.Time)).Seconds())
+		if err != nil {
+			metrics.DBErrors.WithLabelValues(operation, table).Inc()
+			slog.ErrorContext(db.Statement.Context, "database statement failed", "operation", operation, "table", table, "err", err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Transaction statuses
+const (
+	TxPending   = "pending"
+	TxMined     = "mined"
+	TxConfirmed = "confirmed"
+	TxFailed    = "failed"
+	TxDropped   = "dropped"
+	TxReplaced  = "replaced" // superseded by a fee bump; final once the nonce is used
+)
+
+// Transaction records an update transaction sent by this service
+type Transaction struct {
+	TxHash        string `gorm:"primaryKey"`
+	Feed          string `gorm:"not null;index"`
+	Chain         string `gorm:"not null;index:idx_transactions_chain_status"`
+	FromAddress   string `gorm:"not null"`
+	ToAddress     string
+	Nonce         uint64 `gorm:"not null"`
+	Answer        string `gorm:"not null"`
+	Input         string // hex-encoded calldata, kept so the tx can be re-signed
+	GasLimit      uint64
+	GasFeeCap     string // wei; equal to GasTipCap for legacy transactions
+	GasTipCap     string // wei
+	SentAtBlock   uint64
+	Replaces      string `gorm:"index"`
+	ReplacedBy    string
+	Status        string `gorm:"not null;index:idx_transactions_chain_status"`
+	BlockNumber   uint64
+	BlockHash     string
+	Confirmations uint64
+	GasUsed       uint64
+	Error         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// IsFinal reports whether the transaction status can no longer change
+func (t *Transaction) IsFinal() bool {
+	return t.Status == TxConfirmed || t.Status == TxFailed || t.Status == TxDropped
+}
+
+// SaveTransaction inserts or replaces a transaction record
+func (d *DB) SaveTransaction(ctx context.Context, tx *Transaction) error {
+	return d.db.WithContext(ctx).Save(tx).Error
+}
+
+// GetTransaction retrieves a transaction by hash
+func (d *DB) GetTransaction(ctx context.Context, txHash string) (*Transaction, error) {
+	var tx Transaction
+	err := d.db.WithContext(ctx).Where("tx_hash = ?", txHash).First(&tx).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// ReplaceTransaction marks old as replaced by replacement and inserts the
+// replacement in a single transaction
+func (d *DB) ReplaceTransaction(ctx context.Context, old, replacement *Transaction) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		old.Status = TxReplaced
+		old.ReplacedBy = replacement.TxHash
+		if err := tx.Save(old).Error; err != nil {
+			return err
+		}
+
+		replacement.Replaces = old.TxHash
+		return tx.Create(replacement).Error
+	})
+}
+
+// ListOpenTransactions retrieves the transactions on a chain whose status can
+// still change
+func (d *DB) ListOpenTransactions(ctx context.Context, chain string) ([]*Transaction, error) {
+	var txs []*Transaction
+	err := d.db.WithContext(ctx).
+		Where("chain = ? AND status IN ?", chain, []string{TxPending, TxMined, TxReplaced}).
+		Order("created_at").
+		Find(&txs).Error
+	return txs, err
+}
@@ -0,0 +1,187 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Event is a message published on a topic
+type Event struct {
+	Topic string
+	Data  interface{}
+}
+
+// HealthTopic carries a health snapshot whenever the service health changes
+const HealthTopic = "health"
+
+// RoundsTopic is the topic carrying *db.OracleRound values for a feed
+func RoundsTopic(feed string) string {
+	return "rounds:" + feed
+}
+
+// TxTopic is the topic carrying *TxUpdate values for a transaction hash
+func TxTopic(hash string) string {
+	return "tx:" + hash
+}
+
+// TxUpdate is published when a tracked transaction changes status. Receipt
+// is set when the transaction has been mined and the receipt was at hand.
+type TxUpdate struct {
+	Transaction *db.Transaction
+	Receipt     *types.Receipt
+}
+
+// Bus fans published events out to in-process subscribers. Publishing never
+// blocks: a subscriber whose buffer is full is closed instead, so one slow
+// client cannot hold up the producer or the other subscribers.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription receives the events of the topics it is subscribed to
+type Subscription struct {
+	bus    *Bus
+	ch     chan Event
+	mu     sync.RWMutex
+	topics map[string]bool
+	closed bool
+	slow   bool
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe creates a subscription with room for buffer undelivered events
+func (b *Bus) Subscribe(buffer int, topics ...string) *Subscription {
+	sub := &Subscription{
+		bus:    b,
+		ch:     make(chan Event, buffer),
+		topics: make(map[string]bool),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		sub.closed = true
+		close(sub.ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers an event to every subscriber of topic
+func (b *Bus) Publish(topic string, data interface{}) {
+	event := Event{Topic: topic, Data: data}
+
+	b.mu.RLock()
+	var overflowed []*Subscription
+	for sub := range b.subs {
+		if !sub.Has(topic) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			overflowed = append(overflowed, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range overflowed {
+		sub.mu.Lock()
+		sub.slow = true
+		sub.mu.Unlock()
+		sub.Close()
+	}
+}
+
+// Close ends every subscription, e.g. so streaming handlers return on shutdown
+func (b *Bus) Close() {
+	b.mu.Lock()
+	b.closed = true
+	subs := make([]*Subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+// C returns the channel events are delivered on. It is closed when the
+// subscription is closed.
+func (s *Subscription) C() <-chan Event {
+	return s.ch
+}
+
+// Add subscribes to more topics
+func (s *Subscription) Add(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+}
+
+// Remove unsubscribes from topics
+func (s *Subscription) Remove(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+}
+
+// Has reports whether the subscription receives topic
+func (s *Subscription) Has(topic string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.topics[topic]
+}
+
+// Topics returns the number of subscribed topics
+func (s *Subscription) Topics() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.topics)
+}
+
+// Slow reports whether the subscription was closed because it fell behind
+func (s *Subscription) Slow() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.slow
+}
+
+// Close removes the subscription from the bus and closes its channel
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
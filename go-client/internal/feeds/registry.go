@@ -0,0 +1,70 @@
+package feeds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/reader"
+	"github.com/114windd/oracle-client/internal/rpc"
+	"github.com/114windd/oracle-client/internal/updater"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Feed is a single oracle contract served by the API
+type Feed struct {
+	Name    string
+	Address common.Address
+	Chain   string
+	Reader  *reader.Reader
+	Updater *updater.Updater // nil for read-only feeds
+	RPC     *rpc.Pool        // RPC providers of the feed's chain, may be nil
+
+	// MaxPriceAge is how old the latest round may be before it is stale;
+	// 0 disables the check
+	MaxPriceAge time.Duration
+}
+
+// Registry holds the configured feeds by name
+type Registry struct {
+	feeds map[string]*Feed
+	order []string
+}
+
+// NewRegistry creates an empty feed registry
+func NewRegistry() *Registry {
+	return &Registry{feeds: make(map[string]*Feed)}
+}
+
+// Add registers a feed. The first feed added becomes the default feed.
+func (r *Registry) Add(feed *Feed) error {
+	if _, exists := r.feeds[feed.Name]; exists {
+		return fmt.Errorf("feed %q already registered", feed.Name)
+	}
+
+	r.feeds[feed.Name] = feed
+	r.order = append(r.order, feed.Name)
+	return nil
+}
+
+// Get looks up a feed by name
+func (r *Registry) Get(name string) (*Feed, bool) {
+	feed, ok := r.feeds[name]
+	return feed, ok
+}
+
+// Default returns the feed served by the un-namespaced routes
+func (r *Registry) Default() *Feed {
+	if len(r.order) == 0 {
+		return nil
+	}
+	return r.feeds[r.order[0]]
+}
+
+// All returns every feed in registration order
+func (r *Registry) All() []*Feed {
+	feeds := make([]*Feed, 0, len(r.order))
+	for _, name := range r.order {
+		feeds = append(feeds, r.feeds[name])
+	}
+	return feeds
+}
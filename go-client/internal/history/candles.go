@@ -0,0 +1,79 @@
+package history
+
+import (
+	"math/big"
+	"time"
+)
+
+// Candle summarizes the rounds updated in [Start, Start+interval). Count is
+// zero for a bucket without rounds, whose prices all carry the previous
+// close forward. Incomplete marks a bucket overlapping a gap: its prices
+// only cover the rounds known, and are nil if there are none.
+type Candle struct {
+	Start      time.Time
+	Open       *big.Int
+	High       *big.Int
+	Low        *big.Int
+	Close      *big.Int
+	Count      int
+	Incomplete bool
+}
+
+// BucketStart returns the start of the interval-aligned bucket holding t.
+// Buckets are aligned to the unix epoch, so daily buckets start at midnight
+// UTC.
+func BucketStart(t time.Time, interval time.Duration) time.Time {
+	return t.Truncate(interval)
+}
+
+// Candles aggregates points, sorted by UpdatedAt, into the buckets from the
+// one starting at start up to, but excluding, the one starting at end. prev
+// is the last point before start, if any; buckets before the first known
+// price are left out, as are their gaps.
+func Candles(prev *Point, points []Point, gaps []Gap, start, end time.Time, interval time.Duration) []Candle {
+	var candles []Candle
+	var last *big.Int
+	if prev != nil {
+		last = prev.Answer
+	}
+
+	i := 0
+	for bucket := start; bucket.Before(end); bucket = bucket.Add(interval) {
+		next := bucket.Add(interval)
+
+		// Skip points before the range, which the caller should not pass
+		for i < len(points) && points[i].UpdatedAt.Before(bucket) {
+			i++
+		}
+
+		var candle *Candle
+		for ; i < len(points) && points[i].UpdatedAt.Before(next); i++ {
+			answer := points[i].Answer
+			if candle == nil {
+				candle = &Candle{Start: bucket, Open: answer, High: answer, Low: answer}
+			}
+			if answer.Cmp(candle.High) > 0 {
+				candle.High = answer
+			}
+			if answer.Cmp(candle.Low) < 0 {
+				candle.Low = answer
+			}
+			candle.Close = answer
+			candle.Count++
+		}
+
+		incomplete := missing(gaps, bucket, next) > 0
+		switch {
+		case candle != nil:
+			last = candle.Close
+			candle.Incomplete = incomplete
+			candles = append(candles, *candle)
+		case last == nil:
+		case incomplete:
+			candles = append(candles, Candle{Start: bucket, Incomplete: true})
+		default:
+			candles = append(candles, Candle{Start: bucket, Open: last, High: last, Low: last, Close: last})
+		}
+	}
+	return candles
+}
@@ -0,0 +1,116 @@
+package history
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// at returns the time sec seconds after the epoch
+func at(sec int64) time.Time {
+	return time.Unix(sec, 0).UTC()
+}
+
+func point(roundId uint64, sec, answer int64) Point {
+	return Point{RoundID: roundId, UpdatedAt: at(sec), Answer: big.NewInt(answer)}
+}
+
+// describe formats a candle as "<start> <open>/<high>/<low>/<close> n=<count>",
+// followed by " incomplete" if it is
+func describe(c Candle) string {
+	s := fmt.Sprintf("%d %v/%v/%v/%v n=%d", c.Start.Unix(), c.Open, c.High, c.Low, c.Close, c.Count)
+	if c.Incomplete {
+		s += " incomplete"
+	}
+	return s
+}
+
+func TestBucketStart(t *testing.T) {
+	tests := []struct {
+		t        time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{at(0), time.Minute, at(0)},
+		{at(59), time.Minute, at(0)},
+		{at(60), time.Minute, at(60)},
+		{at(3725), time.Hour, at(3600)},
+		{time.Date(2024, 5, 1, 17, 30, 0, 0, time.UTC), 24 * time.Hour, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := BucketStart(tt.t, tt.interval); !got.Equal(tt.want) {
+			t.Errorf("BucketStart(%s, %s) = %s, want %s", tt.t, tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestCandles(t *testing.T) {
+	tests := []struct {
+		name   string
+		prev   *Point
+		points []Point
+		gaps   []Gap
+		end    int64
+		want   []string
+	}{
+		{
+			name: "open, high, low and close per bucket",
+			points: []Point{
+				point(1, 10, 100), point(2, 20, 120), point(3, 30, 90), point(4, 50, 110),
+				point(5, 70, 105),
+			},
+			end: 120,
+			want: []string{
+				"0 100/120/90/110 n=4",
+				"60 105/105/105/105 n=1",
+			},
+		},
+		{
+			name:   "empty buckets carry the close forward",
+			prev:   &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)},
+			points: []Point{point(2, 130, 101)},
+			end:    180,
+			want: []string{
+				"0 100/100/100/100 n=0",
+				"60 100/100/100/100 n=0",
+				"120 101/101/101/101 n=1",
+			},
+		},
+		{
+			name:   "buckets before the first price are left out",
+			points: []Point{point(1, 70, 100)},
+			end:    180,
+			want: []string{
+				"60 100/100/100/100 n=1",
+				"120 100/100/100/100 n=0",
+			},
+		},
+		{
+			name:   "buckets overlapping a gap are incomplete",
+			prev:   &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)},
+			points: []Point{point(3, 130, 102)},
+			gaps:   []Gap{{Start: at(0), End: at(130)}},
+			end:    240,
+			want: []string{
+				"0 <nil>/<nil>/<nil>/<nil> n=0 incomplete",
+				"60 <nil>/<nil>/<nil>/<nil> n=0 incomplete",
+				"120 102/102/102/102 n=1 incomplete",
+				"180 102/102/102/102 n=0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles := Candles(tt.prev, tt.points, tt.gaps, at(0), at(tt.end), time.Minute)
+
+			got := make([]string, len(candles))
+			for i, c := range candles {
+				got[i] = describe(c)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("candles =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package history
+
+import "time"
+
+// Gap is a span in which rounds are missing, so the answer in effect is
+// unknown
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FindGaps returns the spans of [start, end) in which rounds may be missing.
+// Round IDs are consecutive on chain, so rounds are missing between two
+// points whose IDs are not, and after the last point if the round following
+// it is not next, the ID of the first round updated at or after end. prev
+// and points are as for TimeWeighted.
+func FindGaps(prev *Point, points []Point, next uint64, start, end time.Time) []Gap {
+	var all []Point
+	if prev != nil {
+		all = append(all, *prev)
+	}
+	all = append(all, points...)
+
+	var gaps []Gap
+	for i, p := range all {
+		nextID, until := next, end
+		if i+1 < len(all) {
+			nextID, until = all[i+1].RoundID, all[i+1].UpdatedAt
+		}
+		if p.RoundID+1 >= nextID {
+			continue
+		}
+
+		gap := Gap{Start: p.UpdatedAt, End: until}
+		if gap.Start.Before(start) {
+			gap.Start = start
+		}
+		if gap.End.After(end) {
+			gap.End = end
+		}
+		if gap.End.After(gap.Start) {
+			gaps = append(gaps, gap)
+		}
+	}
+	return gaps
+}
+
+// missing returns how much of [from, to) the gaps cover. gaps must be sorted
+// and must not overlap, as FindGaps returns them.
+func missing(gaps []Gap, from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, gap := range gaps {
+		start, end := gap.Start, gap.End
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
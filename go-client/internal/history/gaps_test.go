@@ -0,0 +1,83 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFindGaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		prev   *Point
+		points []Point
+		next   uint64
+		want   []Gap
+	}{
+		{
+			name:   "consecutive rounds",
+			prev:   &Point{RoundID: 1, UpdatedAt: at(-10)},
+			points: []Point{point(2, 10, 0), point(3, 50, 0)},
+			next:   4,
+		},
+		{
+			name:   "rounds missing between points",
+			prev:   &Point{RoundID: 1, UpdatedAt: at(-10)},
+			points: []Point{point(2, 10, 0), point(4, 50, 0)},
+			next:   5,
+			want:   []Gap{{Start: at(10), End: at(50)}},
+		},
+		{
+			name:   "rounds missing after the last point",
+			points: []Point{point(1, 10, 0)},
+			next:   3,
+			want:   []Gap{{Start: at(10), End: at(100)}},
+		},
+		{
+			name:   "gap after the previous round starts at the window",
+			prev:   &Point{RoundID: 1, UpdatedAt: at(-10)},
+			points: []Point{point(3, 20, 0)},
+			next:   4,
+			want:   []Gap{{Start: at(0), End: at(20)}},
+		},
+		{
+			name: "only the previous round",
+			prev: &Point{RoundID: 1, UpdatedAt: at(-10)},
+			next: 2,
+		},
+		{
+			name: "rounds missing after the previous round",
+			prev: &Point{RoundID: 1, UpdatedAt: at(-10)},
+			next: 3,
+			want: []Gap{{Start: at(0), End: at(100)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindGaps(tt.prev, tt.points, tt.next, at(0), at(100))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("FindGaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	gaps := []Gap{{Start: at(10), End: at(20)}, {Start: at(50), End: at(80)}}
+
+	tests := []struct {
+		from, to int64
+		want     time.Duration
+	}{
+		{0, 100, 40 * time.Second},
+		{0, 10, 0},
+		{15, 60, 15 * time.Second},
+		{20, 50, 0},
+		{60, 70, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := missing(gaps, at(tt.from), at(tt.to)); got != tt.want {
+			t.Errorf("missing(%d, %d) = %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package history
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Point is the answer a round set at UpdatedAt. Answers are int256 values,
+// kept as big.Int so that no precision is lost.
+type Point struct {
+	RoundID   uint64
+	UpdatedAt time.Time
+	Answer    *big.Int
+}
+
+// NewPoint parses a decimal answer as stored in the database
+func NewPoint(roundId uint64, updatedAt time.Time, answer string) (Point, error) {
+	value, ok := new(big.Int).SetString(answer, 10)
+	if !ok {
+		return Point{}, fmt.Errorf("round %d: invalid answer %q", roundId, answer)
+	}
+	return Point{RoundID: roundId, UpdatedAt: updatedAt, Answer: value}, nil
+}
@@ -0,0 +1,80 @@
+package history
+
+import (
+	"math/big"
+	"time"
+)
+
+// Contribution is a point and how long its answer is known to have been in
+// effect within a TWAP window
+type Contribution struct {
+	Point
+	Duration time.Duration
+}
+
+// TWAP is a time-weighted average price over [Start, End)
+type TWAP struct {
+	Start time.Time
+	End   time.Time
+
+	// Value is the average, truncated to an integer like the answers
+	Value *big.Int
+
+	// Covered is how much of the window had a known price, and Coverage
+	// its share of the window from 0 to 1. Gaps are not covered.
+	Covered  time.Duration
+	Coverage float64
+
+	Contributions []Contribution
+}
+
+// TimeWeighted averages the answers in effect during [start, end), each
+// weighted by how long it held until the next point or end, less the time
+// spent in gaps. points must be sorted by UpdatedAt and lie within the
+// window; prev is the last point before start, if any, and holds from start.
+// ok is false if no answer is known to have been in effect during the window.
+func TimeWeighted(prev *Point, points []Point, gaps []Gap, start, end time.Time) (twap TWAP, ok bool) {
+	twap = TWAP{Start: start, End: end}
+
+	var all []Point
+	if prev != nil {
+		held := *prev
+		held.UpdatedAt = start
+		all = append(all, held)
+	}
+	all = append(all, points...)
+
+	sum := new(big.Int)
+	for i, p := range all {
+		until := end
+		if i+1 < len(all) {
+			until = all[i+1].UpdatedAt
+		}
+		if until.After(end) {
+			until = end
+		}
+		if p.UpdatedAt.Before(start) || !until.After(p.UpdatedAt) {
+			continue
+		}
+
+		duration := until.Sub(p.UpdatedAt) - missing(gaps, p.UpdatedAt, until)
+		if duration <= 0 {
+			continue
+		}
+		contribution := Contribution{Point: p, Duration: duration}
+		if i == 0 && prev != nil {
+			contribution.Point = *prev
+		}
+		twap.Contributions = append(twap.Contributions, contribution)
+
+		twap.Covered += duration
+		sum.Add(sum, new(big.Int).Mul(p.Answer, big.NewInt(int64(duration))))
+	}
+
+	if twap.Covered == 0 {
+		return twap, false
+	}
+	twap.Value = sum.Quo(sum, big.NewInt(int64(twap.Covered)))
+	twap.Coverage = float64(twap.Covered) / float64(end.Sub(start))
+	return twap, true
+}
@@ -0,0 +1,112 @@
+package history
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestTimeWeighted(t *testing.T) {
+	tests := []struct {
+		name          string
+		prev          *Point
+		points        []Point
+		gaps          []Gap
+		want          int64
+		coverage      float64
+		contributions int
+		wantOK        bool
+	}{
+		{
+			name:          "previous answer holds the whole window",
+			prev:          &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)},
+			want:          100,
+			coverage:      1,
+			contributions: 1,
+			wantOK:        true,
+		},
+		{
+			name:          "answers weighted by how long they held",
+			prev:          &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)},
+			points:        []Point{point(2, 75, 200)},
+			want:          125,
+			coverage:      1,
+			contributions: 2,
+			wantOK:        true,
+		},
+		{
+			name:          "time before the first answer is not covered",
+			points:        []Point{point(1, 50, 200)},
+			want:          200,
+			coverage:      0.5,
+			contributions: 1,
+			wantOK:        true,
+		},
+		{
+			name: "gaps are neither weighted nor covered",
+			prev: &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)},
+			points: []Point{
+				point(2, 10, 110), point(5, 50, 150),
+			},
+			gaps: []Gap{{Start: at(10), End: at(50)}},
+			// (10s * 100 + 50s * 150) / 60s, truncated
+			want:          141,
+			coverage:      0.6,
+			contributions: 2,
+			wantOK:        true,
+		},
+		{
+			name:   "no answer known",
+			wantOK: false,
+		},
+		{
+			name:   "window entirely in a gap",
+			prev:   &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)},
+			gaps:   []Gap{{Start: at(0), End: at(100)}},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			twap, ok := TimeWeighted(tt.prev, tt.points, tt.gaps, at(0), at(100))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if twap.Value.Int64() != tt.want {
+				t.Errorf("value = %s, want %d", twap.Value, tt.want)
+			}
+			if diff := twap.Coverage - tt.coverage; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("coverage = %v, want %v", twap.Coverage, tt.coverage)
+			}
+			if len(twap.Contributions) != tt.contributions {
+				t.Errorf("contributions = %+v, want %d", twap.Contributions, tt.contributions)
+			}
+		})
+	}
+}
+
+func TestTimeWeightedContributions(t *testing.T) {
+	prev := &Point{RoundID: 1, UpdatedAt: at(-10), Answer: big.NewInt(100)}
+	twap, _ := TimeWeighted(prev, []Point{point(2, 40, 200)}, nil, at(0), at(100))
+
+	// The previous round is reported as it was stored, not moved to start
+	want := []Contribution{
+		{Point: *prev, Duration: 40 * time.Second},
+		{Point: point(2, 40, 200), Duration: 60 * time.Second},
+	}
+	if len(twap.Contributions) != len(want) {
+		t.Fatalf("contributions = %+v, want %+v", twap.Contributions, want)
+	}
+	for i, c := range twap.Contributions {
+		w := want[i]
+		if c.RoundID != w.RoundID || !c.UpdatedAt.Equal(w.UpdatedAt) || c.Answer.Cmp(w.Answer) != 0 || c.Duration != w.Duration {
+			t.Errorf("contribution %d = %+v, want %+v", i, c, w)
+		}
+	}
+	if twap.Covered != 100*time.Second {
+		t.Errorf("covered = %s, want 100s", twap.Covered)
+	}
+}
@@ -0,0 +1,299 @@
+package indexer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/contracts"
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/events"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Indexer backfills AnswerUpdated events into Postgres and then follows new
+// blocks, once they have enough confirmations. Rounds stored once the indexer
+// has caught up with the chain head are also published on the bus; those of
+// the initial backfill are not news to subscribers.
+type Indexer struct {
+	client       *ethclient.Client
+	filterer     *contracts.MockOracleFilterer
+	db           *db.DB
+	bus          *events.Bus
+	feed         string
+	name         string
+	startBlock   uint64
+	batchSize    uint64
+	pollInterval time.Duration
+
+	// confirmations a block needs, counting itself, before it is indexed
+	confirmations uint64
+
+	// live is set once a backfill reached the chain head
+	live bool
+}
+
+// New creates a new indexer for the named feed at contractAddress. The
+// checkpoint is keyed by feed so several indexers can share one table.
+func New(client *ethclient.Client, feed string, contractAddress common.Address, database *db.DB, bus *events.Bus, startBlock, batchSize, confirmations uint64, pollInterval time.Duration) (*Indexer, error) {
+	filterer, err := contracts.NewMockOracleFilterer(contractAddress, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
+	return &Indexer{
+		client:       client,
+		filterer:     filterer,
+		db:           database,
+		bus:          bus,
+		feed:         feed,
+		name:         "answer_updated:" + feed,
+		startBlock:   startBlock,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+
+		confirmations: confirmations,
+	}, nil
+}
+
+// Run indexes until ctx is cancelled. It resumes from the stored checkpoint,
+// backfills to the last confirmed block, then keeps polling with
+// FilterAnswerUpdated. Only with a single confirmation, and a node that
+// supports them, does it subscribe to new events instead.
+func (i *Indexer) Run(ctx context.Context) error {
+	for {
+		next, err := i.backfill(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.ErrorContext(ctx, "indexer backfill failed", "feed", i.feed, "err", err)
+		} else if err := i.watch(ctx, next); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.WarnContext(ctx, "indexer subscription ended", "feed", i.feed, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(i.pollInterval):
+		}
+	}
+}
+
+// backfill processes all blocks from the checkpoint up to the last confirmed
+// one and returns the first block that has not been processed yet
+func (i *Indexer) backfill(ctx context.Context) (uint64, error) {
+	from, err := i.resumeBlock(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	latest, err := i.client.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	head, ok := confirmedHead(latest, i.confirmations)
+	if !ok {
+		return from, nil
+	}
+
+	for from <= head {
+		end := from + i.batchSize - 1
+		if end > head {
+			end = head
+		}
+
+		if err := i.indexRange(ctx, from, end); err != nil {
+			return 0, err
+		}
+		from = end + 1
+	}
+
+	if !i.live {
+		slog.InfoContext(ctx, "indexer caught up with the chain head", "feed", i.feed, "block", head)
+		i.live = true
+	}
+	return from, nil
+}
+
+// confirmedHead returns the last block with the given number of
+// confirmations, counting itself, when latest is the chain head. Logs polled
+// from blocks closer to the head could still be reorged away, and
+// FilterAnswerUpdated never reports them as removed.
+func confirmedHead(latest, confirmations uint64) (uint64, bool) {
+	if latest+1 < confirmations {
+		return 0, false
+	}
+	return latest - (confirmations - 1), true
+}
+
+// indexRange stores every AnswerUpdated event in [from, end] and checkpoints end
+func (i *Indexer) indexRange(ctx context.Context, from, end uint64) error {
+	it, err := i.filterer.FilterAnswerUpdated(&bind.FilterOpts{Start: from, End: &end, Context: ctx}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	var rounds []*db.OracleRound
+	for it.Next() {
+		if it.Event.Raw.Removed {
+			if err := i.remove(ctx, it.Event); err != nil {
+				return err
+			}
+			continue
+		}
+		rounds = append(rounds, i.toRound(it.Event))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	if err := i.db.SaveIndexedRounds(ctx, i.name, rounds, end); err != nil {
+		return err
+	}
+	i.publish(rounds)
+
+	if len(rounds) > 0 {
+		slog.InfoContext(ctx, "indexer stored rounds", "feed", i.feed, "rounds", len(rounds), "from_block", from, "to_block", end)
+	}
+	return nil
+}
+
+// watch follows new events starting at block from until the subscription
+// fails. A subscription delivers logs as soon as they are mined, so with more
+// than one confirmation the indexer polls instead.
+func (i *Indexer) watch(ctx context.Context, from uint64) error {
+	if i.confirmations > 1 {
+		slog.InfoContext(ctx, "indexer polling for confirmed events", "feed", i.feed, "confirmations", i.confirmations, "interval", i.pollInterval)
+		return i.poll(ctx)
+	}
+
+	sink := make(chan *contracts.MockOracleAnswerUpdated, 16)
+	sub, err := i.filterer.WatchAnswerUpdated(&bind.WatchOpts{Start: &from, Context: ctx}, sink, nil, nil)
+	if err != nil {
+		slog.InfoContext(ctx, "indexer subscriptions unavailable, polling", "feed", i.feed, "err", err, "interval", i.pollInterval)
+		return i.poll(ctx)
+	}
+	defer sub.Unsubscribe()
+
+	slog.InfoContext(ctx, "indexer watching AnswerUpdated", "feed", i.feed, "from_block", from)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			return err
+		case event := <-sink:
+			if event.Raw.Removed {
+				if err := i.remove(ctx, event); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Everything before this block has already been delivered, so the
+			// checkpoint can safely move to the previous block
+			checkpoint := event.Raw.BlockNumber
+			if checkpoint > 0 {
+				checkpoint--
+			}
+			rounds := []*db.OracleRound{i.toRound(event)}
+			if err := i.db.SaveIndexedRounds(ctx, i.name, rounds, checkpoint); err != nil {
+				return err
+			}
+			i.publish(rounds)
+		}
+	}
+}
+
+// poll keeps backfilling on a timer
+func (i *Indexer) poll(ctx context.Context) error {
+	ticker := time.NewTicker(i.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := i.backfill(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// remove deletes the round stored from a log that a reorg removed. If the
+// round was mined again, its new log stores it once more.
+func (i *Indexer) remove(ctx context.Context, event *contracts.MockOracleAnswerUpdated) error {
+	slog.WarnContext(ctx, "indexer round removed by reorg", "feed", i.feed, "round", event.RoundId, "block", event.Raw.BlockNumber, "tx", event.Raw.TxHash.Hex())
+
+	round := i.toRound(event)
+	deleted, err := i.db.DeleteIndexedRound(ctx, i.feed, round.RoundID, round.TxHash, round.BlockNumber, round.LogIndex)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		slog.InfoContext(ctx, "indexer deleted reorged round", "feed", i.feed, "round", round.RoundID)
+	}
+	return nil
+}
+
+// publish announces stored rounds to stream subscribers, once the indexer
+// follows the chain head
+func (i *Indexer) publish(rounds []*db.OracleRound) {
+	if i.bus == nil || !i.live {
+		return
+	}
+	for _, round := range rounds {
+		i.bus.Publish(events.RoundsTopic(i.feed), round)
+	}
+}
+
+// resumeBlock returns the block to start scanning from
+func (i *Indexer) resumeBlock(ctx context.Context) (uint64, error) {
+	checkpoint, ok, err := i.db.GetCheckpoint(ctx, i.name)
+	if err != nil {
+		return 0, err
+	}
+	if !ok || checkpoint < i.startBlock {
+		return i.startBlock, nil
+	}
+	return checkpoint + 1, nil
+}
+
+// toRound converts an AnswerUpdated event into a stored round. MockOracle sets
+// startedAt to updatedAt and answeredInRound to the round itself on every update.
+func (i *Indexer) toRound(event *contracts.MockOracleAnswerUpdated) *db.OracleRound {
+	updatedAt := time.Unix(event.UpdatedAt.Int64(), 0)
+	roundId := event.RoundId.Uint64()
+
+	return &db.OracleRound{
+		Feed:            i.feed,
+		RoundID:         roundId,
+		Answer:          event.Current.String(),
+		StartedAt:       updatedAt,
+		UpdatedAt:       updatedAt,
+		AnsweredInRound: roundId,
+		TxHash:          event.Raw.TxHash.Hex(),
+		BlockNumber:     event.Raw.BlockNumber,
+		LogIndex:        event.Raw.Index,
+	}
+}
@@ -0,0 +1,23 @@
+package indexer
+
+import "testing"
+
+func TestConfirmedHead(t *testing.T) {
+	tests := []struct {
+		latest, confirmations uint64
+		want                  uint64
+		wantOK                bool
+	}{
+		{100, 1, 100, true},
+		{100, 12, 89, true},
+		{11, 12, 0, true},
+		{10, 12, 0, false},
+		{0, 1, 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := confirmedHead(tt.latest, tt.confirmations)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("confirmedHead(%d, %d) = %d, %v, want %d, %v", tt.latest, tt.confirmations, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "oracle"
+
+// HTTP metrics
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	ResponseSources = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "api",
+		Name:      "responses_total",
+		Help:      "Round data responses by endpoint and the layer that served them (cache, db, rpc or last_known).",
+	}, []string{"endpoint", "source"})
+
+	FeedRoundAge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "api",
+		Name:      "feed_round_age_seconds",
+		Help:      "Age of each feed's latest on-chain round as of the last health check.",
+	}, []string{"feed"})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter by route pattern and the limit that was exceeded (ip, client or route).",
+	}, []string{"route", "limit"})
+)
+
+// Cache metrics
+var (
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Cache lookups by key class and result (hit, miss or error).",
+	}, []string{"class", "result"})
+
+	CacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "write_errors_total",
+		Help:      "Failed cache writes and deletes by key class and operation.",
+	}, []string{"class", "operation"})
+)
+
+// Database metrics
+var (
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database statement latency by operation and table.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "table"})
+
+	DBErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "errors_total",
+		Help:      "Failed database statements by operation and table. Missing records are not errors.",
+	}, []string{"operation", "table"})
+)
+
+// RPC metrics
+var (
+	RPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "request_duration_seconds",
+		Help:      "JSON-RPC request latency by chain, provider and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"chain", "provider", "method"})
+
+	RPCErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "errors_total",
+		Help:      "Failed JSON-RPC requests, including JSON-RPC error responses, by chain, provider and method.",
+	}, []string{"chain", "provider", "method"})
+
+	RPCFailovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "failovers_total",
+		Help:      "JSON-RPC requests retried on another provider after one failed, by chain.",
+	}, []string{"chain"})
+
+	RPCProviderBlockLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "provider_block_lag",
+		Help:      "Blocks a provider's head is behind the highest head seen on its chain.",
+	}, []string{"chain", "provider"})
+)
+
+// BreakerState is 0 while an RPC provider's circuit breaker is closed, 1
+// while half-open and 2 while open
+var BreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "rpc",
+	Name:      "breaker_state",
+	Help:      "RPC circuit breaker state by chain and provider: 0 closed, 1 half-open, 2 open.",
+}, []string{"chain", "provider"})
+
+// Retry metrics
+var (
+	RetryAttempts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retry",
+		Name:      "attempts_total",
+		Help:      "Attempts made by retry policies, including the first.",
+	})
+
+	RetryCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retry",
+		Name:      "calls_total",
+		Help:      "Retried operations by outcome (success, exhausted, permanent or cancelled).",
+	}, []string{"outcome"})
+)
+
+// Transaction metrics
+var (
+	TxSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "updater",
+		Name:      "transactions_sent_total",
+		Help:      "Update transactions broadcast by feed and kind (new or replacement).",
+	}, []string{"feed", "kind"})
+
+	TxStatus = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "updater",
+		Name:      "transaction_status_total",
+		Help:      "Update transaction status changes by feed and new status.",
+	}, []string{"feed", "status"})
+
+	TxGasUsed = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "updater",
+		Name:      "gas_used",
+		Help:      "Gas used by mined update transactions by feed.",
+		Buckets:   prometheus.ExponentialBuckets(21000, 1.5, 10),
+	}, []string{"feed"})
+
+	SignerBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "updater",
+		Name:      "signer_balance_eth",
+		Help:      "Balance of each feed's updater account in ether.",
+	}, []string{"feed", "address"})
+)
+
+// Handler serves all registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,165 @@
+package nonce
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/114windd/oracle-client/internal/retry"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// OpenTransactions reports the transactions sent from an address that are
+// not final yet
+type OpenTransactions interface {
+	// NextNonce returns one past the highest nonce of the open transactions
+	// sent from address. ok is false if there are none.
+	NextNonce(ctx context.Context, from common.Address) (next uint64, ok bool, err error)
+}
+
+// Manager hands out nonces for one signer address. Allocation and broadcast
+// are serialized so concurrent updates never reuse a nonce.
+type Manager struct {
+	mu      sync.Mutex
+	client  *ethclient.Client
+	open    OpenTransactions
+	address common.Address
+	next    uint64
+	synced  bool
+}
+
+// NewManager creates a nonce manager for address. The first allocation syncs
+// from the node's pending nonce, so a restart picks up where the node is.
+// After that the local nonce is only reconciled with the node when it
+// rejects one. open may be nil.
+func NewManager(client *ethclient.Client, open OpenTransactions, address common.Address) *Manager {
+	return &Manager{
+		client:  client,
+		open:    open,
+		address: address,
+	}
+}
+
+// Do calls send with the next nonce while holding the allocation lock. The
+// nonce is consumed if send succeeds, and also if the broadcast failed
+// ambiguously (see retry.IsAmbiguous): the node may hold the transaction, so
+// reusing its nonce would replace it or be rejected until it is mined. send
+// should record such a transaction as pending, so that it keeps the nonce
+// reserved and is resolved or replaced like any other. If the node reports
+// the nonce as too low or too high, the manager resyncs and tries once more.
+func (m *Manager) Do(ctx context.Context, send func(nonce uint64) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		if err := m.sync(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := send(m.next)
+	if err != nil && isNonceError(err) {
+		slog.WarnContext(ctx, "nonce rejected, resyncing", "address", m.address.Hex(), "nonce", m.next, "err", err)
+		if syncErr := m.sync(ctx); syncErr != nil {
+			return syncErr
+		}
+		err = send(m.next)
+	}
+	if err != nil && retry.IsAmbiguous(err) {
+		slog.WarnContext(ctx, "broadcast outcome unknown, keeping nonce reserved", "address", m.address.Hex(), "nonce", m.next, "err", err)
+		m.next++
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	m.next++
+	return nil
+}
+
+// sync reconciles the local nonce with the node's pending nonce. The node
+// may lag behind, e.g. a failover provider that has not seen our in-flight
+// transactions, so while any of them is still open the nonce never moves
+// below them or below what was already handed out. Must be called with mu
+// held.
+func (m *Manager) sync(ctx context.Context) error {
+	pending, err := m.client.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return err
+	}
+
+	var floor uint64
+	if m.open != nil {
+		next, ok, err := m.open.NextNonce(ctx, m.address)
+		if err != nil {
+			return err
+		}
+		if ok {
+			floor = next
+			if m.synced {
+				floor = max(floor, m.next)
+			}
+		}
+	}
+
+	next := max(pending, floor)
+	if m.synced {
+		switch {
+		case next > m.next:
+			slog.WarnContext(ctx, "node nonce is ahead, another sender is using this key", "address", m.address.Hex(), "pending", pending, "local", m.next)
+		case next < m.next:
+			slog.WarnContext(ctx, "nonce gap detected, transactions are unknown to the node", "address", m.address.Hex(), "from_nonce", next, "to_nonce", m.next-1)
+		}
+	}
+	if pending < floor {
+		slog.WarnContext(ctx, "node is behind our open transactions, keeping local nonce", "address", m.address.Hex(), "pending", pending, "local", next)
+	}
+
+	m.next = next
+	m.synced = true
+	return nil
+}
+
+// isNonceError reports whether err means the local nonce is out of step with
+// the node. "replacement transaction underpriced" is not one: the slot holds
+// our own transaction, and resending with another nonce would duplicate it.
+func isNonceError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") ||
+		strings.Contains(msg, "nonce too high")
+}
+
+// Registry shares one Manager per signer address on a chain, so feeds
+// updated with the same key do not race each other
+type Registry struct {
+	mu       sync.Mutex
+	client   *ethclient.Client
+	open     OpenTransactions
+	managers map[common.Address]*Manager
+}
+
+// NewRegistry creates a nonce manager registry for one chain. open reports
+// the transactions still in flight on it and may be nil.
+func NewRegistry(client *ethclient.Client, open OpenTransactions) *Registry {
+	return &Registry{
+		client:   client,
+		open:     open,
+		managers: make(map[common.Address]*Manager),
+	}
+}
+
+// For returns the manager for address, creating it on first use
+func (r *Registry) For(address common.Address) *Manager {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	manager, ok := r.managers[address]
+	if !ok {
+		manager = NewManager(r.client, r.open, address)
+		r.managers[address] = manager
+	}
+	return manager
+}
@@ -0,0 +1,268 @@
+package nonce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/114windd/oracle-client/internal/retry"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var sender = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+// node serves eth_getTransactionCount with the pending nonce it holds
+type node struct {
+	pending atomic.Uint64
+	calls   atomic.Int64
+}
+
+func newNode(t *testing.T, pending uint64) (*node, *ethclient.Client) {
+	t.Helper()
+
+	n := &node{}
+	n.pending.Store(pending)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_getTransactionCount" {
+			http.Error(w, "unexpected method "+req.Method, http.StatusBadRequest)
+			return
+		}
+		n.calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": hexutil.EncodeUint64(n.pending.Load())})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return n, client
+}
+
+// openTxs reports a fixed next nonce of open transactions
+type openTxs struct {
+	next uint64
+	ok   bool
+}
+
+func (o openTxs) NextNonce(ctx context.Context, from common.Address) (uint64, bool, error) {
+	return o.next, o.ok, nil
+}
+
+// sendAll allocates count nonces, failing the test on an error
+func sendAll(t *testing.T, m *Manager, count int) []uint64 {
+	t.Helper()
+	var nonces []uint64
+	for range count {
+		err := m.Do(context.Background(), func(nonce uint64) error {
+			nonces = append(nonces, nonce)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	return nonces
+}
+
+func TestManagerConcurrentAllocation(t *testing.T) {
+	_, client := newNode(t, 10)
+	m := NewManager(client, nil, sender)
+
+	const workers = 50
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := m.Do(context.Background(), func(nonce uint64) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if seen[nonce] {
+					t.Errorf("nonce %d handed out twice", nonce)
+				}
+				seen[nonce] = true
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Do: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for nonce := uint64(10); nonce < 10+workers; nonce++ {
+		if !seen[nonce] {
+			t.Errorf("nonce %d was skipped", nonce)
+		}
+	}
+}
+
+func TestManagerSyncsOnce(t *testing.T) {
+	n, client := newNode(t, 3)
+	m := NewManager(client, nil, sender)
+
+	if got := fmt.Sprint(sendAll(t, m, 3)); got != "[3 4 5]" {
+		t.Errorf("nonces = %s, want [3 4 5]", got)
+	}
+	// A lagging node must not pull the local nonce back between sends
+	n.pending.Store(0)
+	if got := fmt.Sprint(sendAll(t, m, 1)); got != "[6]" {
+		t.Errorf("nonce after the node lagged = %s, want [6]", got)
+	}
+	if calls := n.calls.Load(); calls != 1 {
+		t.Errorf("node queried %d times, want once", calls)
+	}
+}
+
+func TestManagerResyncsOnNonceError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     string
+		pending uint64
+		want    string
+	}{
+		{"nonce too low", "nonce too low: next nonce 8, tx nonce 5", 8, "[5 8]"},
+		{"nonce too high", "nonce too high", 2, "[5 2]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, client := newNode(t, 5)
+			m := NewManager(client, nil, sender)
+
+			var tried []uint64
+			err := m.Do(context.Background(), func(nonce uint64) error {
+				tried = append(tried, nonce)
+				if len(tried) == 1 {
+					n.pending.Store(tt.pending)
+					return errors.New(tt.err)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := fmt.Sprint(tried); got != tt.want {
+				t.Errorf("tried nonces %s, want %s", got, tt.want)
+			}
+			if next := sendAll(t, m, 1)[0]; next != tt.pending+1 {
+				t.Errorf("next nonce = %d, want %d", next, tt.pending+1)
+			}
+		})
+	}
+}
+
+func TestManagerFloor(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending uint64
+		open    openTxs
+		want    uint64
+	}{
+		{"no open transactions", 4, openTxs{}, 4},
+		{"node has seen them", 9, openTxs{next: 9, ok: true}, 9},
+		{"lagging node", 4, openTxs{next: 9, ok: true}, 9},
+		{"node ahead of our transactions", 12, openTxs{next: 9, ok: true}, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newNode(t, tt.pending)
+			m := NewManager(client, tt.open, sender)
+			if got := sendAll(t, m, 1)[0]; got != tt.want {
+				t.Errorf("nonce = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerResyncKeepsHandedOutNonces(t *testing.T) {
+	// After a failover to a node that has not seen our transactions, a
+	// nonce error must not move the nonce below those still open
+	n, client := newNode(t, 5)
+	m := NewManager(client, openTxs{next: 7, ok: true}, sender)
+	sendAll(t, m, 3) // 7, 8, 9
+
+	n.pending.Store(5)
+	var tried []uint64
+	m.Do(context.Background(), func(nonce uint64) error {
+		tried = append(tried, nonce)
+		if len(tried) == 1 {
+			return errors.New("nonce too high")
+		}
+		return nil
+	})
+	if got := fmt.Sprint(tried); got != "[10 10]" {
+		t.Errorf("tried nonces %s, want [10 10]", got)
+	}
+}
+
+func TestManagerSendFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		consumed bool
+	}{
+		{"signing failed", errors.New("signer unavailable"), false},
+		{"node rejected", retry.SendFailed(rpcError("insufficient funds for gas")), false},
+		{"replacement underpriced", retry.SendFailed(rpcError("replacement transaction underpriced")), false},
+		{"broadcast timed out", retry.SendFailed(context.DeadlineExceeded), true},
+		{"connection dropped", retry.SendFailed(errors.New("read: connection reset by peer")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newNode(t, 5)
+			m := NewManager(client, nil, sender)
+
+			err := m.Do(context.Background(), func(nonce uint64) error { return tt.err })
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Do error = %v, want %v", err, tt.err)
+			}
+
+			want := uint64(5)
+			if tt.consumed {
+				want = 6
+			}
+			if next := sendAll(t, m, 1)[0]; next != want {
+				t.Errorf("next nonce = %d, want %d", next, want)
+			}
+		})
+	}
+}
+
+// rpcError is a JSON-RPC error response from the node
+type rpcError string
+
+func (e rpcError) Error() string  { return string(e) }
+func (e rpcError) ErrorCode() int { return -32000 }
+
+func TestRegistryShared(t *testing.T) {
+	_, client := newNode(t, 0)
+	r := NewRegistry(client, nil)
+
+	other := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	if r.For(sender) != r.For(sender) {
+		t.Error("same address got different managers")
+	}
+	if r.For(sender) == r.For(other) {
+		t.Error("different addresses share a manager")
+	}
+}
@@ -0,0 +1,305 @@
+package pusher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outlier rejection methods
+const (
+	OutlierMAD  = "mad"  // drop quotes more than Threshold scaled MADs from the median
+	OutlierBand = "band" // drop quotes more than Threshold percent from the median
+)
+
+// Source participation statuses
+const (
+	SourceUsed    = "used"
+	SourceStale   = "stale"
+	SourceOutlier = "outlier"
+	SourceError   = "error"
+)
+
+// madScale makes the median absolute deviation comparable to a standard
+// deviation for normally distributed prices
+var madScale = big.NewRat(14826, 10000)
+
+// ErrNoQuorum is returned when too few sources survive filtering
+var ErrNoQuorum = errors.New("not enough price sources for quorum")
+
+// SourceConfig is one aggregated source and how long it may take to answer
+type SourceConfig struct {
+	Source  PriceSource
+	Timeout time.Duration
+}
+
+// AggregatorConfig controls filtering and quorum
+type AggregatorConfig struct {
+	Quorum        int           // minimum sources left after filtering
+	MaxAge        time.Duration // quotes older than this are stale; 0 disables the check
+	OutlierMethod string        // OutlierMAD or OutlierBand
+	Threshold     float64       // MAD multiple or band percent
+	MinBand       float64       // percent from the median always accepted by OutlierMAD
+}
+
+// SourceResult records how one source took part in an aggregation
+type SourceResult struct {
+	Name      string
+	Price     *big.Rat
+	Timestamp time.Time
+	Status    string
+	Error     string
+}
+
+// Result is an aggregated price scaled to the oracle's decimals
+type Result struct {
+	Answer  *big.Int
+	Price   *big.Rat
+	Sources []SourceResult
+}
+
+// Summary lists every source with its status, for logs
+func (r *Result) Summary() string {
+	parts := make([]string, 0, len(r.Sources))
+	for _, s := range r.Sources {
+		switch {
+		case s.Price != nil:
+			parts = append(parts, fmt.Sprintf("%s=%s(%s)", s.Name, s.Price.FloatString(8), s.Status))
+		default:
+			parts = append(parts, fmt.Sprintf("%s(%s: %s)", s.Name, s.Status, s.Error))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Aggregator queries several sources in parallel and combines them into one
+// median price after dropping failed, stale and outlying quotes
+type Aggregator struct {
+	sources   []SourceConfig
+	quorum    int
+	maxAge    time.Duration
+	method    string
+	threshold *big.Rat
+	minBand   *big.Rat
+}
+
+// NewAggregator creates an aggregator over sources
+func NewAggregator(sources []SourceConfig, cfg AggregatorConfig) (*Aggregator, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("at least one price source is required")
+	}
+
+	quorum := cfg.Quorum
+	if quorum <= 0 {
+		quorum = 1
+	}
+	if quorum > len(sources) {
+		return nil, fmt.Errorf("quorum %d exceeds the %d configured sources", quorum, len(sources))
+	}
+
+	method := cfg.OutlierMethod
+	if method == "" {
+		method = OutlierMAD
+	}
+	if method != OutlierMAD && method != OutlierBand {
+		return nil, fmt.Errorf("unknown outlier method %q", method)
+	}
+
+	threshold := new(big.Rat)
+	if threshold.SetFloat64(cfg.Threshold) == nil || threshold.Sign() < 0 {
+		return nil, fmt.Errorf("invalid outlier threshold %v", cfg.Threshold)
+	}
+	minBand := new(big.Rat)
+	if minBand.SetFloat64(cfg.MinBand) == nil || minBand.Sign() < 0 {
+		return nil, fmt.Errorf("invalid outlier minimum band %v", cfg.MinBand)
+	}
+
+	return &Aggregator{
+		sources:   sources,
+		quorum:    quorum,
+		maxAge:    cfg.MaxAge,
+		method:    method,
+		threshold: threshold,
+		minBand:   minBand,
+	}, nil
+}
+
+// Aggregate fetches every source and returns the median of the surviving
+// quotes scaled to decimals. On ErrNoQuorum the result still lists every source.
+func (a *Aggregator) Aggregate(ctx context.Context, decimals uint8) (*Result, error) {
+	result := &Result{Sources: a.fetchAll(ctx)}
+
+	// Drop failed and stale quotes
+	now := time.Now()
+	var fresh []*SourceResult
+	for i := range result.Sources {
+		s := &result.Sources[i]
+		if s.Status == SourceError {
+			continue
+		}
+		if a.maxAge > 0 && now.Sub(s.Timestamp) > a.maxAge {
+			s.Status = SourceStale
+			s.Error = fmt.Sprintf("quote is %s old", now.Sub(s.Timestamp).Round(time.Second))
+			continue
+		}
+		fresh = append(fresh, s)
+	}
+
+	if len(fresh) < a.quorum {
+		return result, fmt.Errorf("%w: %d of %d required", ErrNoQuorum, len(fresh), a.quorum)
+	}
+
+	// Drop outliers relative to the median of the fresh quotes
+	used := a.rejectOutliers(fresh)
+	if len(used) < a.quorum {
+		return result, fmt.Errorf("%w: %d of %d required after outlier rejection", ErrNoQuorum, len(used), a.quorum)
+	}
+
+	prices := make([]*big.Rat, len(used))
+	for i, s := range used {
+		prices[i] = s.Price
+	}
+
+	result.Price = median(prices)
+	result.Answer = ScalePrice(result.Price, decimals)
+	return result, nil
+}
+
+// fetchAll queries every source concurrently, each under its own timeout
+func (a *Aggregator) fetchAll(ctx context.Context) []SourceResult {
+	results := make([]SourceResult, len(a.sources))
+
+	var wg sync.WaitGroup
+	for i, sc := range a.sources {
+		wg.Add(1)
+		go func(i int, sc SourceConfig) {
+			defer wg.Done()
+
+			fetchCtx := ctx
+			if sc.Timeout > 0 {
+				var cancel context.CancelFunc
+				fetchCtx, cancel = context.WithTimeout(ctx, sc.Timeout)
+				defer cancel()
+			}
+
+			res := SourceResult{Name: sc.Source.Name()}
+			quote, err := sc.Source.Fetch(fetchCtx)
+			if err != nil {
+				res.Status = SourceError
+				res.Error = err.Error()
+			} else {
+				res.Price = quote.Price
+				res.Timestamp = quote.Timestamp
+				res.Status = SourceUsed
+			}
+			results[i] = res
+		}(i, sc)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// rejectOutliers marks quotes too far from the median as outliers and
+// returns the rest
+func (a *Aggregator) rejectOutliers(quotes []*SourceResult) []*SourceResult {
+	prices := make([]*big.Rat, len(quotes))
+	for i, s := range quotes {
+		prices[i] = s.Price
+	}
+	mid := median(prices)
+
+	deviations := make([]*big.Rat, len(quotes))
+	for i, s := range quotes {
+		d := new(big.Rat).Sub(s.Price, mid)
+		deviations[i] = d.Abs(d)
+	}
+
+	// limit is the largest deviation from the median that is accepted
+	var limit *big.Rat
+	switch a.method {
+	case OutlierMAD:
+		mad := median(deviations)
+		limit = new(big.Rat).Mul(mad, madScale)
+		limit.Mul(limit, a.threshold)
+
+		// When half the quotes agree exactly, as rounded exchange prices
+		// often do, MAD is 0 and any other quote would be an outlier
+		if floor := percentOf(mid, a.minBand); limit.Cmp(floor) < 0 {
+			limit = floor
+		}
+	case OutlierBand:
+		limit = percentOf(mid, a.threshold)
+	}
+
+	var kept []*SourceResult
+	for i, s := range quotes {
+		if deviations[i].Cmp(limit) > 0 {
+			s.Status = SourceOutlier
+			s.Error = fmt.Sprintf("%s from median %s", deviations[i].FloatString(8), mid.FloatString(8))
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
+
+// percentOf returns percent percent of the magnitude of value
+func percentOf(value, percent *big.Rat) *big.Rat {
+	r := new(big.Rat).Abs(value)
+	r.Mul(r, percent)
+	return r.Quo(r, big.NewRat(100, 1))
+}
+
+// median returns the median of values, averaging the middle pair for an even count
+func median(values []*big.Rat) *big.Rat {
+	sorted := make([]*big.Rat, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return new(big.Rat).Set(sorted[n/2])
+	}
+
+	sum := new(big.Rat).Add(sorted[n/2-1], sorted[n/2])
+	return sum.Quo(sum, big.NewRat(2, 1))
+}
+
+// ParseSourceList parses comma-separated source specs. Each spec may end with
+// ";timeout=<duration>" to override defaultTimeout for that source.
+func ParseSourceList(value string, defaultTimeout time.Duration) ([]SourceConfig, error) {
+	var sources []SourceConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		spec, options, _ := strings.Cut(entry, ";")
+		timeout := defaultTimeout
+		if options != "" {
+			raw, ok := strings.CutPrefix(options, "timeout=")
+			if !ok {
+				return nil, fmt.Errorf("unknown option %q for source %q", options, spec)
+			}
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for source %q: %w", spec, err)
+			}
+			timeout = d
+		}
+
+		source, err := NewSource(spec, timeout)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, SourceConfig{Source: source, Timeout: timeout})
+	}
+	return sources, nil
+}
@@ -0,0 +1,272 @@
+package pusher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// staticSource returns a fixed quote or error
+type staticSource struct {
+	name  string
+	price string
+	age   time.Duration
+	err   error
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) Fetch(ctx context.Context) (Quote, error) {
+	if s.err != nil {
+		return Quote{}, s.err
+	}
+	price, _ := new(big.Rat).SetString(s.price)
+	return Quote{Price: price, Timestamp: time.Now().Add(-s.age)}, nil
+}
+
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid rational " + s)
+	}
+	return r
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"single", []string{"5"}, "5"},
+		{"odd", []string{"3", "1", "2"}, "2"},
+		{"even averages the middle pair", []string{"4", "1", "3", "2"}, "5/2"},
+		{"duplicates", []string{"7", "7", "1"}, "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := make([]*big.Rat, len(tt.values))
+			for i, v := range tt.values {
+				values[i] = rat(v)
+			}
+			if got := median(values); got.Cmp(rat(tt.want)) != 0 {
+				t.Errorf("median(%v) = %s, want %s", tt.values, got.RatString(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRejectOutliers(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		threshold float64
+		minBand   float64
+		prices    []string
+		outliers  []bool
+	}{
+		{
+			name:      "mad keeps close quotes",
+			method:    OutlierMAD,
+			threshold: 3,
+			prices:    []string{"100", "101", "99", "100.5"},
+			outliers:  []bool{false, false, false, false},
+		},
+		{
+			name:      "mad drops a far quote",
+			method:    OutlierMAD,
+			threshold: 3,
+			prices:    []string{"100", "101", "99", "150"},
+			outliers:  []bool{false, false, false, true},
+		},
+		{
+			name:      "zero mad falls back to the minimum band",
+			method:    OutlierMAD,
+			threshold: 3,
+			minBand:   0.1,
+			prices:    []string{"2000", "2000", "2000.01"},
+			outliers:  []bool{false, false, false},
+		},
+		{
+			name:      "zero mad still drops quotes beyond the minimum band",
+			method:    OutlierMAD,
+			threshold: 3,
+			minBand:   0.1,
+			prices:    []string{"2000", "2000", "2010"},
+			outliers:  []bool{false, false, true},
+		},
+		{
+			name:      "zero mad without a minimum band",
+			method:    OutlierMAD,
+			threshold: 3,
+			prices:    []string{"2000", "2000", "2000.01"},
+			outliers:  []bool{false, false, true},
+		},
+		{
+			name:      "band keeps quotes within the percent",
+			method:    OutlierBand,
+			threshold: 1,
+			prices:    []string{"100", "100.9", "99.2"},
+			outliers:  []bool{false, false, false},
+		},
+		{
+			name:      "band drops quotes beyond the percent",
+			method:    OutlierBand,
+			threshold: 1,
+			prices:    []string{"100", "101.5", "99.5"},
+			outliers:  []bool{false, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := make([]SourceConfig, len(tt.prices))
+			for i := range tt.prices {
+				sources[i] = SourceConfig{Source: staticSource{name: "s", price: tt.prices[i]}}
+			}
+			a, err := NewAggregator(sources, AggregatorConfig{OutlierMethod: tt.method, Threshold: tt.threshold, MinBand: tt.minBand})
+			if err != nil {
+				t.Fatalf("NewAggregator: %v", err)
+			}
+
+			quotes := make([]*SourceResult, len(tt.prices))
+			for i, p := range tt.prices {
+				quotes[i] = &SourceResult{Price: rat(p), Status: SourceUsed}
+			}
+			a.rejectOutliers(quotes)
+
+			for i, q := range quotes {
+				if got := q.Status == SourceOutlier; got != tt.outliers[i] {
+					t.Errorf("quote %s: outlier = %v, want %v", tt.prices[i], got, tt.outliers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []staticSource
+		quorum  int
+		maxAge  time.Duration
+		want    string // answer at 2 decimals
+		wantErr error
+	}{
+		{
+			name:    "median of all sources",
+			sources: []staticSource{{name: "a", price: "100"}, {name: "b", price: "101"}, {name: "c", price: "102.5"}},
+			quorum:  3,
+			want:    "10100",
+		},
+		{
+			name:    "failed source is skipped",
+			sources: []staticSource{{name: "a", price: "100"}, {name: "b", err: errors.New("down")}, {name: "c", price: "102"}},
+			quorum:  2,
+			want:    "10100",
+		},
+		{
+			name:    "stale source is skipped",
+			sources: []staticSource{{name: "a", price: "100"}, {name: "b", price: "200", age: time.Hour}, {name: "c", price: "102"}},
+			quorum:  2,
+			maxAge:  time.Minute,
+			want:    "10100",
+		},
+		{
+			name:    "too few fresh sources",
+			sources: []staticSource{{name: "a", price: "100"}, {name: "b", err: errors.New("down")}},
+			quorum:  2,
+			wantErr: ErrNoQuorum,
+		},
+		{
+			name:    "identical quotes keep quorum",
+			sources: []staticSource{{name: "a", price: "2000"}, {name: "b", price: "2000"}, {name: "c", price: "2000.01"}},
+			quorum:  3,
+			want:    "200000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := make([]SourceConfig, len(tt.sources))
+			for i, s := range tt.sources {
+				sources[i] = SourceConfig{Source: s}
+			}
+			a, err := NewAggregator(sources, AggregatorConfig{Quorum: tt.quorum, MaxAge: tt.maxAge, Threshold: 3, MinBand: 0.1})
+			if err != nil {
+				t.Fatalf("NewAggregator: %v", err)
+			}
+
+			result, err := a.Aggregate(context.Background(), 2)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Aggregate error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Aggregate: %v [%s]", err, result.Summary())
+			}
+			if got := result.Answer.String(); got != tt.want {
+				t.Errorf("answer = %s, want %s [%s]", got, tt.want, result.Summary())
+			}
+		})
+	}
+}
+
+func TestNewAggregatorValidation(t *testing.T) {
+	sources := []SourceConfig{{Source: staticSource{name: "a", price: "1"}}}
+	tests := []struct {
+		name string
+		cfg  AggregatorConfig
+	}{
+		{"quorum above sources", AggregatorConfig{Quorum: 2}},
+		{"unknown method", AggregatorConfig{OutlierMethod: "zscore"}},
+		{"negative threshold", AggregatorConfig{Threshold: -1}},
+		{"negative minimum band", AggregatorConfig{MinBand: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAggregator(sources, tt.cfg); err == nil {
+				t.Error("NewAggregator succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestScalePrice(t *testing.T) {
+	tests := []struct {
+		price    string
+		decimals uint8
+		want     string
+	}{
+		{"2000.12345678", 8, "200012345678"},
+		{"2000.123456789", 8, "200012345678"},
+		{"1", 0, "1"},
+		{"0.5", 0, "0"},
+		{"1/3", 4, "3333"},
+	}
+	for _, tt := range tests {
+		if got := ScalePrice(rat(tt.price), tt.decimals).String(); got != tt.want {
+			t.Errorf("ScalePrice(%s, %d) = %s, want %s", tt.price, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestDeviation(t *testing.T) {
+	tests := []struct {
+		current, answer int64
+		want            string
+	}{
+		{100, 100, "0"},
+		{100, 101, "1"},
+		{100, 99, "1"},
+		{-200, -199, "1/2"},
+	}
+	for _, tt := range tests {
+		got := Deviation(big.NewInt(tt.current), big.NewInt(tt.answer))
+		if got.Cmp(rat(tt.want)) != 0 {
+			t.Errorf("Deviation(%d, %d) = %s, want %s", tt.current, tt.answer, got.RatString(), tt.want)
+		}
+	}
+}
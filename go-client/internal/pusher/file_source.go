@@ -0,0 +1,39 @@
+package pusher
+
+import (
+	"context"
+	"os"
+)
+
+// FileSource reads a price from a local file on every fetch. It lets the
+// pusher run fully offline, e.g. with a file updated by a cron job or by hand.
+type FileSource struct {
+	path  string
+	field string
+}
+
+// NewFileSource creates a source reading field from the file at path
+func NewFileSource(path, field string) *FileSource {
+	return &FileSource{path: path, field: field}
+}
+
+// Name identifies the source in logs
+func (s *FileSource) Name() string {
+	return "file:" + s.path
+}
+
+// Fetch reads the file. Quotes without a timestamp are dated by the file's
+// modification time.
+func (s *FileSource) Fetch(ctx context.Context) (Quote, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	return parseQuote(raw, s.field, info.ModTime())
+}
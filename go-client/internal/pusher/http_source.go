@@ -0,0 +1,63 @@
+package pusher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxResponseBytes bounds how much of a source response is read
+const maxResponseBytes = 1 << 20
+
+// HTTPSource reads a price from a JSON endpoint
+type HTTPSource struct {
+	url    string
+	field  string
+	client *http.Client
+}
+
+// NewHTTPSource creates a source reading field from the JSON served at url
+func NewHTTPSource(url, field string, timeout time.Duration) *HTTPSource {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &HTTPSource{
+		url:    url,
+		field:  field,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name identifies the source in logs
+func (s *HTTPSource) Name() string {
+	return s.url
+}
+
+// Fetch requests the endpoint. Quotes without a timestamp are dated now.
+func (s *HTTPSource) Fetch(ctx context.Context) (Quote, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Quote{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return Quote{}, err
+	}
+
+	return parseQuote(raw, s.field, time.Now())
+}
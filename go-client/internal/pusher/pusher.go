@@ -0,0 +1,195 @@
+package pusher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/feeds"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Pusher keeps a feed in line with an off-chain price. It updates the oracle
+// when the aggregated price deviates by more than the threshold or when the
+// heartbeat has elapsed since the last on-chain update.
+type Pusher struct {
+	feed         *feeds.Feed
+	aggregator   *Aggregator
+	deviation    *big.Rat // percent
+	heartbeat    time.Duration
+	pollInterval time.Duration
+	txTimeout    time.Duration
+
+	decimals       uint8
+	decimalsLoaded bool
+
+	// inflight is the last update sent, until it is final. No other update
+	// is pushed meanwhile, so a slow confirmation does not stack a second
+	// transaction on top of the fee bumps of the first.
+	inflight common.Hash
+}
+
+// New creates a pusher for feed. deviationPercent of 0 pushes on heartbeat only,
+// and a heartbeat of 0 pushes on deviation only.
+func New(feed *feeds.Feed, aggregator *Aggregator, deviationPercent float64, heartbeat, pollInterval, txTimeout time.Duration) (*Pusher, error) {
+	if feed.Updater == nil {
+		return nil, fmt.Errorf("feed %s has no updater key", feed.Name)
+	}
+
+	deviation := new(big.Rat)
+	if deviation.SetFloat64(deviationPercent) == nil || deviation.Sign() < 0 {
+		return nil, fmt.Errorf("invalid deviation threshold %v", deviationPercent)
+	}
+
+	if pollInterval <= 0 {
+		pollInterval = 10 * time.Second
+	}
+	if txTimeout <= 0 {
+		txTimeout = 60 * time.Second
+	}
+
+	return &Pusher{
+		feed:         feed,
+		aggregator:   aggregator,
+		deviation:    deviation,
+		heartbeat:    heartbeat,
+		pollInterval: pollInterval,
+		txTimeout:    txTimeout,
+	}, nil
+}
+
+// Run polls the source until ctx is cancelled
+func (p *Pusher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.tick(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "pusher tick failed", "feed", p.feed.Name, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick fetches one quote and pushes it if the deviation or heartbeat requires
+func (p *Pusher) tick(ctx context.Context) error {
+	if p.inflight != (common.Hash{}) {
+		if final, err := p.wait(ctx); !final || err != nil {
+			return err
+		}
+	}
+
+	if !p.decimalsLoaded {
+		decimals, err := p.feed.Reader.GetDecimals(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read decimals: %w", err)
+		}
+		p.decimals, p.decimalsLoaded = decimals, true
+	}
+	decimals := p.decimals
+
+	result, err := p.aggregator.Aggregate(ctx, decimals)
+	if err != nil {
+		return fmt.Errorf("%w [%s]", err, result.Summary())
+	}
+
+	answer := result.Answer
+	if answer.Sign() <= 0 {
+		return fmt.Errorf("aggregated price %s is not positive [%s]", result.Price.FloatString(int(decimals)), result.Summary())
+	}
+
+	_, current, _, updatedAt, _, err := p.feed.Reader.GetLatestRoundData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read latest round: %w", err)
+	}
+
+	reason := p.pushReason(current, answer, time.Unix(updatedAt.Int64(), 0))
+	if reason == "" {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "pushing price", "feed", p.feed.Name, "answer", answer, "onchain", current, "reason", reason, "sources", result.Summary())
+
+	txHash, err := p.feed.Updater.UpdatePrice(ctx, answer)
+	if err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+
+	// Wait for the update so the next tick compares against the new round
+	// instead of pushing the same price again
+	p.inflight = txHash
+	_, err = p.wait(ctx)
+	return err
+}
+
+// wait waits up to the transaction timeout for the in-flight update to become
+// final, and reports whether it did. The update is forgotten once final, or
+// if it can't be looked up at all.
+func (p *Pusher) wait(ctx context.Context) (final bool, err error) {
+	txHash := p.inflight
+
+	waitCtx, cancel := context.WithTimeout(ctx, p.txTimeout)
+	defer cancel()
+
+	txRecord, _, err := p.feed.Updater.WaitForTransaction(waitCtx, txHash)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		if txRecord == nil {
+			p.inflight = common.Hash{}
+		}
+		return false, fmt.Errorf("failed to track %s: %w", txHash.Hex(), err)
+	}
+	if txRecord == nil || !txRecord.IsFinal() {
+		slog.InfoContext(ctx, "update still pending, not pushing another", "feed", p.feed.Name, "tx", txHash.Hex())
+		return false, nil
+	}
+
+	p.inflight = common.Hash{}
+	if txRecord.Status != db.TxConfirmed {
+		return true, fmt.Errorf("update %s %s: %s", txRecord.TxHash, txRecord.Status, txRecord.Error)
+	}
+	return true, nil
+}
+
+// pushReason explains why answer should be pushed over current, or returns
+// an empty string if no update is needed
+func (p *Pusher) pushReason(current, answer *big.Int, updatedAt time.Time) string {
+	if p.heartbeat > 0 && time.Since(updatedAt) >= p.heartbeat {
+		return fmt.Sprintf("heartbeat of %s elapsed", p.heartbeat)
+	}
+
+	if current.Sign() == 0 {
+		return "no current answer"
+	}
+
+	deviation := Deviation(current, answer)
+	if p.deviation.Sign() > 0 && deviation.Cmp(p.deviation) >= 0 {
+		return fmt.Sprintf("deviation %s%% >= %s%%", deviation.FloatString(4), p.deviation.FloatString(4))
+	}
+	return ""
+}
+
+// Deviation returns |answer - current| / |current| as a percentage
+func Deviation(current, answer *big.Int) *big.Rat {
+	diff := new(big.Int).Sub(answer, current)
+	diff.Abs(diff)
+	diff.Mul(diff, big.NewInt(100))
+
+	return new(big.Rat).SetFrac(diff, new(big.Int).Abs(current))
+}
+
+// ScalePrice converts a decimal price to the oracle's integer representation,
+// truncating digits beyond decimals
+func ScalePrice(price *big.Rat, decimals uint8) *big.Int {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	scaled := new(big.Rat).Mul(price, new(big.Rat).SetInt(scale))
+	return new(big.Int).Quo(scaled.Num(), scaled.Denom())
+}
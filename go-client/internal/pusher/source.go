@@ -0,0 +1,126 @@
+package pusher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Quote is a price reported by a source, as an exact decimal
+type Quote struct {
+	Price     *big.Rat
+	Timestamp time.Time
+}
+
+// PriceSource is implemented by every upstream the pusher can read prices from
+type PriceSource interface {
+	Name() string
+	Fetch(ctx context.Context) (Quote, error)
+}
+
+// defaultPriceField is the JSON field read when a source spec has no #fragment
+const defaultPriceField = "price"
+
+// NewSource creates a source from a spec string:
+//
+//	file:/path/to/price.json#field   a local file, re-read on every fetch
+//	http://host/path#data.price      a JSON endpoint
+//
+// The optional fragment is a dot-separated path to the price field. The
+// sibling field "timestamp" (unix seconds), if present, dates the quote.
+func NewSource(spec string, timeout time.Duration) (PriceSource, error) {
+	location, field, _ := strings.Cut(spec, "#")
+	if field == "" {
+		field = defaultPriceField
+	}
+
+	switch {
+	case strings.HasPrefix(location, "file:"):
+		return NewFileSource(strings.TrimPrefix(location, "file:"), field), nil
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return NewHTTPSource(location, field, timeout), nil
+	default:
+		return nil, fmt.Errorf("unsupported price source %q", spec)
+	}
+}
+
+// parseQuote reads a quote from a JSON document, or from a bare decimal number.
+// fallback dates the quote when the document has no timestamp.
+func parseQuote(raw []byte, field string, fallback time.Time) (Quote, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if price, ok := new(big.Rat).SetString(trimmed); ok {
+		return Quote{Price: price, Timestamp: fallback}, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return Quote{}, fmt.Errorf("invalid price document: %w", err)
+	}
+
+	path := strings.Split(field, ".")
+	parent, err := lookup(doc, path[:len(path)-1])
+	if err != nil {
+		return Quote{}, err
+	}
+	object, ok := parent.(map[string]interface{})
+	if !ok {
+		return Quote{}, fmt.Errorf("field %q is not inside an object", field)
+	}
+
+	price, err := toRat(object[path[len(path)-1]])
+	if err != nil {
+		return Quote{}, fmt.Errorf("field %q: %w", field, err)
+	}
+
+	quote := Quote{Price: price, Timestamp: fallback}
+	if ts, ok := object["timestamp"]; ok {
+		seconds, err := toRat(ts)
+		if err != nil {
+			return Quote{}, fmt.Errorf("field timestamp: %w", err)
+		}
+		unix, _ := new(big.Float).SetRat(seconds).Int64()
+		quote.Timestamp = time.Unix(unix, 0)
+	}
+	return quote, nil
+}
+
+// lookup walks a decoded JSON document along path
+func lookup(doc interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %q is not an object", key)
+		}
+		if doc, ok = object[key]; !ok {
+			return nil, fmt.Errorf("field %q not found", key)
+		}
+	}
+	return doc, nil
+}
+
+// toRat converts a JSON number or numeric string to an exact rational
+func toRat(value interface{}) (*big.Rat, error) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	case nil:
+		return nil, fmt.Errorf("missing")
+	default:
+		return nil, fmt.Errorf("not a number: %v", v)
+	}
+
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("not a number: %q", text)
+	}
+	return r, nil
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+
+	tests := []struct {
+		name string
+		// elapsed is how far the bucket's clock is wound back before each
+		// request, standing in for time passing
+		elapsed []time.Duration
+		allowed []bool
+		// remaining tokens after the last request
+		remaining int
+	}{
+		{
+			name:      "burst up to the limit",
+			elapsed:   []time.Duration{0, 0, 0},
+			allowed:   []bool{true, true, true},
+			remaining: 0,
+		},
+		{
+			name:      "denied once empty",
+			elapsed:   []time.Duration{0, 0, 0, 0},
+			allowed:   []bool{true, true, true, false},
+			remaining: 0,
+		},
+		{
+			name:      "refills at the limit's rate",
+			elapsed:   []time.Duration{0, 0, 0, time.Second, 0},
+			allowed:   []bool{true, true, true, true, false},
+			remaining: 0,
+		},
+		{
+			name:      "refill is capped at the limit",
+			elapsed:   []time.Duration{0, time.Hour},
+			allowed:   []bool{true, true},
+			remaining: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+			var r Result
+			for i, elapsed := range tt.elapsed {
+				if b, ok := m.buckets["client"]; ok {
+					b.updated = b.updated.Add(-elapsed)
+				}
+
+				var err error
+				r, err = m.Allow(context.Background(), "client", limit)
+				if err != nil {
+					t.Fatalf("Allow: %v", err)
+				}
+				if r.Allowed != tt.allowed[i] {
+					t.Fatalf("request %d: allowed = %v, want %v", i, r.Allowed, tt.allowed[i])
+				}
+			}
+			if r.Remaining != tt.remaining {
+				t.Errorf("remaining = %d, want %d", r.Remaining, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterResult(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Requests: 2, Window: 2 * time.Second}
+
+	r, _ := m.Allow(context.Background(), "client", limit)
+	if r.Limit != limit || r.Remaining != 1 || r.RetryAfter != 0 {
+		t.Errorf("first result = %+v", r)
+	}
+	if r.Reset <= 0 || r.Reset > time.Second {
+		t.Errorf("first reset = %s, want within (0, 1s]", r.Reset)
+	}
+
+	m.Allow(context.Background(), "client", limit)
+	r, _ = m.Allow(context.Background(), "client", limit)
+	if r.Allowed {
+		t.Fatal("third request allowed")
+	}
+	if r.RetryAfter <= 0 || r.RetryAfter > time.Second {
+		t.Errorf("retry after = %s, want within (0, 1s]", r.RetryAfter)
+	}
+	if r.Reset <= time.Second || r.Reset > 2*time.Second {
+		t.Errorf("reset = %s, want within (1s, 2s]", r.Reset)
+	}
+}
+
+func TestMemoryLimiterKeys(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	for _, key := range []string{"a", "b"} {
+		if r, _ := m.Allow(context.Background(), key, limit); !r.Allowed {
+			t.Errorf("first request for %s denied", key)
+		}
+	}
+	if r, _ := m.Allow(context.Background(), "a", limit); r.Allowed {
+		t.Error("second request for a allowed")
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Requests: 1, Window: time.Second}
+	m.Allow(context.Background(), "idle", limit)
+	m.Allow(context.Background(), "busy", limit)
+
+	m.buckets["idle"].full = time.Now().Add(-time.Second)
+	m.buckets["busy"].full = time.Now().Add(time.Hour)
+	m.lastSweep = time.Now().Add(-sweepInterval)
+	m.Allow(context.Background(), "other", limit)
+
+	if _, ok := m.buckets["idle"]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := m.buckets["busy"]; !ok {
+		t.Error("draining bucket was swept")
+	}
+}
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backend names accepted by New
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// Limit allows Requests per Window. Buckets hold up to Requests tokens and
+// refill continuously, so a client may burst up to Requests at once.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// String formats l as ParseLimit accepts it
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Window.String()
+}
+
+// rate is the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// ParseLimit parses "<requests>/<window>", e.g. "100/1m"
+func ParseLimit(value string) (Limit, error) {
+	requests, window, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q, want <requests>/<window>", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: requests must be a positive integer", value)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: window must be a positive duration", value)
+	}
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// ParseRoutes parses a comma-separated list of <pattern>=<limit> entries,
+// where pattern is a ServeMux route pattern, e.g.
+//
+//	POST /admin/keys=5/1m,/feeds/{name}/updatePrice=10/1m
+func ParseRoutes(value string) (map[string]Limit, error) {
+	routes := make(map[string]Limit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, spec, ok := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid route limit %q, want <pattern>=<requests>/<window>", entry)
+		}
+		if _, path, _ := strings.Cut(pattern, " "); !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route pattern %q", pattern)
+		}
+		if _, ok := routes[pattern]; ok {
+			return nil, fmt.Errorf("route %q is listed twice", pattern)
+		}
+
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", pattern, err)
+		}
+		routes[pattern] = limit
+	}
+	return routes, nil
+}
+
+// Policy selects the limits that apply to a request
+type Policy struct {
+	// Default applies to clients without an API key, and to keys whose
+	// scopes have no limit of their own
+	Default Limit
+
+	// Scopes holds limits by API key scope. A key with several scopes gets
+	// the most generous of their limits.
+	Scopes map[string]Limit
+
+	// Routes holds limits by route pattern, applied on top of the client's
+	// limit and counted separately for each client
+	Routes map[string]Limit
+
+	// IP limits every request from one IP before it is authenticated, so
+	// guessing API keys is throttled too. Zero requests disables it.
+	IP Limit
+}
+
+// ForScopes returns the limit of a client holding scopes
+func (p Policy) ForScopes(scopes []string) Limit {
+	var best Limit
+	for _, scope := range scopes {
+		limit, ok := p.Scopes[scope]
+		if ok && (best.Requests == 0 || limit.rate() > best.rate()) {
+			best = limit
+		}
+	}
+	if best.Requests == 0 {
+		return p.Default
+	}
+	return best
+}
+
+// Result is the outcome of one Allow call
+type Result struct {
+	Allowed bool
+	Limit   Limit
+
+	// Remaining whole tokens after this request
+	Remaining int
+
+	// Reset is how long until the bucket is full again, and RetryAfter how
+	// long until the next request would be allowed
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter takes tokens from named buckets
+type Limiter interface {
+	// Allow takes a token from the bucket key, which holds and refills
+	// according to limit
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	Close() error
+}
+
+// Options selects and configures a limiter backend
+type Options struct {
+	Backend string
+
+	// Redis backend
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// New creates the limiter backend selected in opts. The Redis backend shares
+// buckets between every replica using the same Redis.
+func New(opts Options) (Limiter, error) {
+	switch opts.Backend {
+	case BackendRedis, "":
+		return NewRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB), nil
+	case BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", opts.Backend)
+	}
+}
+
+// result describes a bucket holding tokens after a request
+func result(allowed bool, tokens float64, limit Limit) Result {
+	rate := limit.rate()
+	r := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
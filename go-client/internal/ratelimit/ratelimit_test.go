@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{value: "100/1m", want: Limit{Requests: 100, Window: time.Minute}},
+		{value: " 5 / 10s ", want: Limit{Requests: 5, Window: 10 * time.Second}},
+		{value: "100", wantErr: true},
+		{value: "0/1m", wantErr: true},
+		{value: "-1/1m", wantErr: true},
+		{value: "x/1m", wantErr: true},
+		{value: "10/0s", wantErr: true},
+		{value: "10/soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLimit(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]Limit
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: map[string]Limit{}},
+		{
+			name:  "patterns with and without a method",
+			value: "POST /admin/keys=5/1m, /feeds/{name}/updatePrice=10/1m",
+			want: map[string]Limit{
+				"POST /admin/keys":          {Requests: 5, Window: time.Minute},
+				"/feeds/{name}/updatePrice": {Requests: 10, Window: time.Minute},
+			},
+		},
+		{name: "missing limit", value: "/health", wantErr: true},
+		{name: "not a path", value: "health=1/1s", wantErr: true},
+		{name: "listed twice", value: "/a=1/1s,/a=2/1s", wantErr: true},
+		{name: "bad limit", value: "/a=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoutes(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRoutes(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRoutes(%q): %v", tt.value, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseRoutes(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			for pattern, limit := range tt.want {
+				if got[pattern] != limit {
+					t.Errorf("route %q = %v, want %v", pattern, got[pattern], limit)
+				}
+			}
+		})
+	}
+}
+
+func TestForScopes(t *testing.T) {
+	policy := Policy{
+		Default: Limit{Requests: 60, Window: time.Minute},
+		Scopes: map[string]Limit{
+			"read":  {Requests: 600, Window: time.Minute},
+			"admin": {Requests: 20, Window: time.Second},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   Limit
+	}{
+		{"no key", nil, policy.Default},
+		{"scope without a limit", []string{"push"}, policy.Default},
+		{"one scope", []string{"read"}, policy.Scopes["read"]},
+		{"most generous scope", []string{"read", "admin"}, policy.Scopes["admin"]},
+	}
+	for _, tt := range tests {
+		if got := policy.ForScopes(tt.scopes); got != tt.want {
+			t.Errorf("%s: ForScopes(%v) = %v, want %v", tt.name, tt.scopes, got, tt.want)
+		}
+	}
+}
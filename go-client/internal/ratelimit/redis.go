@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces bucket keys in Redis
+const keyPrefix = "ratelimit:"
+
+// takeScript refills and takes a token from the bucket in KEYS[1], a hash of
+// its tokens and last update time in milliseconds. It uses the Redis clock
+// so that replicas agree on elapsed time.
+//
+// ARGV: capacity, refill rate in tokens per millisecond, TTL in milliseconds
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter keeps token buckets in Redis, shared by every replica
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedis creates a new Redis-backed limiter
+func NewRedis(addr, password string, db int) *RedisLimiter {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	return &RedisLimiter{client: client}
+}
+
+// Allow takes a token from the bucket key
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	// An empty bucket refills completely within one window, after which it
+	// is the same as a missing one
+	ttl := limit.Window.Milliseconds() + 1000
+	rate := limit.rate() / 1000
+
+	reply, err := takeScript.Run(ctx, l.client, []string{keyPrefix + key}, limit.Requests, rate, ttl).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	raw, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit tokens %q", raw)
+	}
+	return result(allowed == 1, tokens, limit), nil
+}
+
+// Close closes the Redis connection
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
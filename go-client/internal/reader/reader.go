@@ -2,18 +2,45 @@ package reader
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"math/big"
+	"sync"
+	"time"
 
 	"github.com/114windd/oracle-client/internal/contracts"
+	"github.com/114windd/oracle-client/internal/tracing"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ErrNoQuorum is returned when too few providers agree on latestRoundData
+var ErrNoQuorum = errors.New("rpc providers did not reach quorum")
+
 // Reader handles reading data from the MockOracle contract
 type Reader struct {
-	client *ethclient.Client
-	oracle *contracts.MockOracle
+	client  *ethclient.Client
+	oracle  *contracts.MockOracle
+	address common.Address
+
+	// quorumOracles are bound to one provider each; when set, latestRoundData
+	// is only accepted once quorum of them return the same round
+	quorumOracles []*contracts.MockOracle
+	quorum        int
+
+	metadataMu sync.Mutex
+	metadata   *Metadata
+}
+
+// Metadata describes an oracle contract. The fields are fixed when the
+// contract is deployed.
+type Metadata struct {
+	Decimals    uint8
+	Description string
+	Version     *big.Int
 }
 
 // NewReader creates a new reader instance
@@ -24,14 +51,57 @@ func NewReader(client *ethclient.Client, contractAddress common.Address) (*Reade
 	}
 
 	return &Reader{
-		client: client,
-		oracle: oracle,
+		client:  client,
+		oracle:  oracle,
+		address: contractAddress,
 	}, nil
 }
 
+// EnableQuorum makes latestRoundData query every one of clients, which
+// should each talk to a different provider, and accept a result only once
+// quorum of them return it identically
+func (r *Reader) EnableQuorum(clients []*ethclient.Client, quorum int) error {
+	if quorum < 1 || quorum > len(clients) {
+		return fmt.Errorf("read quorum %d needs between 1 and %d providers", quorum, len(clients))
+	}
+
+	oracles := make([]*contracts.MockOracle, 0, len(clients))
+	for _, client := range clients {
+		oracle, err := contracts.NewMockOracle(r.address, client)
+		if err != nil {
+			return err
+		}
+		oracles = append(oracles, oracle)
+	}
+
+	r.quorumOracles = oracles
+	r.quorum = quorum
+	return nil
+}
+
+// startCall starts a span for a contract call made on behalf of ctx. The
+// returned function ends it and logs the outcome: failures at warn level,
+// successful calls only at debug level.
+func (r *Reader) startCall(ctx context.Context, method string) (context.Context, func(error)) {
+	ctx, span := tracing.Start(ctx, "reader."+method,
+		attribute.String("contract.address", r.address.Hex()),
+		attribute.String("contract.method", method),
+	)
+	start := time.Now()
+
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		if err != nil {
+			slog.WarnContext(ctx, "contract call failed", "contract", r.address.Hex(), "method", method, "duration", time.Since(start), "err", err)
+			return
+		}
+		slog.DebugContext(ctx, "contract call", "contract", r.address.Hex(), "method", method, "duration", time.Since(start))
+	}
+}
+
 // GetLatestPrice retrieves the latest price from the oracle
 func (r *Reader) GetLatestPrice(ctx context.Context) (*big.Int, error) {
-	_, answer, _, _, _, err := r.oracle.LatestRoundData(&bind.CallOpts{Context: ctx})
+	_, answer, _, _, _, err := r.GetLatestRoundData(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -42,17 +112,98 @@ func (r *Reader) GetLatestPrice(ctx context.Context) (*big.Int, error) {
 
 // GetLatestRoundData retrieves all latest round data
 func (r *Reader) GetLatestRoundData(ctx context.Context) (*big.Int, *big.Int, *big.Int, *big.Int, *big.Int, error) {
-	return r.oracle.LatestRoundData(&bind.CallOpts{Context: ctx})
+	ctx, done := r.startCall(ctx, "latestRoundData")
+	if r.quorum > 0 {
+		round, err := r.quorumLatestRoundData(ctx)
+		done(err)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		return round.roundId, round.answer, round.startedAt, round.updatedAt, round.answeredInRound, nil
+	}
+
+	roundId, answer, startedAt, updatedAt, answeredInRound, err := r.oracle.LatestRoundData(&bind.CallOpts{Context: ctx})
+	done(err)
+	return roundId, answer, startedAt, updatedAt, answeredInRound, err
+}
+
+// roundData is one latestRoundData result
+type roundData struct {
+	roundId         *big.Int
+	answer          *big.Int
+	startedAt       *big.Int
+	updatedAt       *big.Int
+	answeredInRound *big.Int
+}
+
+// key identifies identical results
+func (d roundData) key() string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", d.roundId, d.answer, d.startedAt, d.updatedAt, d.answeredInRound)
+}
+
+// quorumLatestRoundData queries every quorum provider at once and returns
+// the first result quorum of them agree on. It gives up as soon as the
+// providers still outstanding can no longer make up a quorum.
+func (r *Reader) quorumLatestRoundData(ctx context.Context) (roundData, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		data roundData
+		err  error
+	}
+	results := make(chan result, len(r.quorumOracles))
+	for _, oracle := range r.quorumOracles {
+		go func() {
+			var res result
+			res.data.roundId, res.data.answer, res.data.startedAt, res.data.updatedAt, res.data.answeredInRound, res.err =
+				oracle.LatestRoundData(&bind.CallOpts{Context: ctx})
+			results <- res
+		}()
+	}
+
+	votes := make(map[string]int)
+	var (
+		best    int
+		lastErr error
+	)
+	for pending := len(r.quorumOracles); pending > 0; pending-- {
+		res := <-results
+		if res.err != nil {
+			lastErr = res.err
+		} else {
+			key := res.data.key()
+			votes[key]++
+			if votes[key] >= r.quorum {
+				return res.data, nil
+			}
+			best = max(best, votes[key])
+		}
+
+		if best+pending-1 < r.quorum {
+			break
+		}
+	}
+
+	if lastErr != nil {
+		return roundData{}, fmt.Errorf("%w: %d of %d needed agree, last error: %w", ErrNoQuorum, best, r.quorum, lastErr)
+	}
+	return roundData{}, fmt.Errorf("%w: %d of %d needed agree", ErrNoQuorum, best, r.quorum)
 }
 
 // GetRoundData retrieves data for a specific round
 func (r *Reader) GetRoundData(ctx context.Context, roundId *big.Int) (*big.Int, *big.Int, *big.Int, *big.Int, *big.Int, error) {
-	return r.oracle.GetRoundData(&bind.CallOpts{Context: ctx}, roundId)
+	ctx, done := r.startCall(ctx, "getRoundData")
+	id, answer, startedAt, updatedAt, answeredInRound, err := r.oracle.GetRoundData(&bind.CallOpts{Context: ctx}, roundId)
+	done(err)
+	return id, answer, startedAt, updatedAt, answeredInRound, err
 }
 
 // GetLatestRoundId retrieves the latest round ID
 func (r *Reader) GetLatestRoundId(ctx context.Context) (*big.Int, error) {
+	ctx, done := r.startCall(ctx, "latestRoundId")
 	roundId, err := r.oracle.LatestRoundId(&bind.CallOpts{Context: ctx})
+	done(err)
 	if err != nil {
 		return nil, err
 	}
@@ -61,15 +212,96 @@ func (r *Reader) GetLatestRoundId(ctx context.Context) (*big.Int, error) {
 
 // GetDecimals retrieves the decimals of the oracle
 func (r *Reader) GetDecimals(ctx context.Context) (uint8, error) {
-	return r.oracle.Decimals(&bind.CallOpts{Context: ctx})
+	ctx, done := r.startCall(ctx, "decimals")
+	value, err := r.oracle.Decimals(&bind.CallOpts{Context: ctx})
+	done(err)
+	return value, err
 }
 
 // GetDescription retrieves the description of the oracle
 func (r *Reader) GetDescription(ctx context.Context) (string, error) {
-	return r.oracle.Description(&bind.CallOpts{Context: ctx})
+	ctx, done := r.startCall(ctx, "description")
+	value, err := r.oracle.Description(&bind.CallOpts{Context: ctx})
+	done(err)
+	return value, err
 }
 
 // GetVersion retrieves the version of the oracle
 func (r *Reader) GetVersion(ctx context.Context) (*big.Int, error) {
-	return r.oracle.Version(&bind.CallOpts{Context: ctx})
+	ctx, done := r.startCall(ctx, "version")
+	value, err := r.oracle.Version(&bind.CallOpts{Context: ctx})
+	done(err)
+	return value, err
+}
+
+// GetOwner retrieves the owner of the oracle
+func (r *Reader) GetOwner(ctx context.Context) (common.Address, error) {
+	ctx, done := r.startCall(ctx, "owner")
+	value, err := r.oracle.Owner(&bind.CallOpts{Context: ctx})
+	done(err)
+	return value, err
+}
+
+// metadataRetryInterval is the wait between attempts of PreloadMetadata
+const metadataRetryInterval = 10 * time.Second
+
+// GetMetadata retrieves the decimals, description and version of the
+// oracle. They never change, so the first successful read is kept for the
+// life of the reader; a failed one is retried on the next call.
+func (r *Reader) GetMetadata(ctx context.Context) (Metadata, error) {
+	if metadata, ok := r.CachedMetadata(); ok {
+		return metadata, nil
+	}
+
+	// Read without holding the lock, so a slow node does not block callers
+	// of CachedMetadata
+	decimals, err := r.GetDecimals(ctx)
+	if err != nil {
+		return Metadata{}, err
+	}
+	description, err := r.GetDescription(ctx)
+	if err != nil {
+		return Metadata{}, err
+	}
+	version, err := r.GetVersion(ctx)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	r.metadataMu.Lock()
+	defer r.metadataMu.Unlock()
+
+	if r.metadata == nil {
+		r.metadata = &Metadata{Decimals: decimals, Description: description, Version: version}
+	}
+	return *r.metadata, nil
+}
+
+// CachedMetadata returns the metadata if it was already read, without
+// calling the node
+func (r *Reader) CachedMetadata() (Metadata, bool) {
+	r.metadataMu.Lock()
+	defer r.metadataMu.Unlock()
+
+	if r.metadata == nil {
+		return Metadata{}, false
+	}
+	return *r.metadata, true
+}
+
+// PreloadMetadata reads the metadata, trying again every few seconds until
+// it succeeds or ctx is cancelled
+func (r *Reader) PreloadMetadata(ctx context.Context) error {
+	for {
+		_, err := r.GetMetadata(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(metadataRetryInterval):
+		}
+	}
 }
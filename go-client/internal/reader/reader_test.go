@@ -0,0 +1,114 @@
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/114windd/oracle-client/internal/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// oracleNode serves eth_call for latestRoundData with the given answer, or
+// fails every request when answer is negative
+func oracleNode(t *testing.T, answer int64) *ethclient.Client {
+	t.Helper()
+
+	parsed, err := contracts.MockOracleMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("parse ABI: %v", err)
+	}
+	output, err := parsed.Methods["latestRoundData"].Outputs.Pack(
+		big.NewInt(7), big.NewInt(answer), big.NewInt(1000), big.NewInt(1000), big.NewInt(7))
+	if err != nil {
+		t.Fatalf("pack latestRoundData: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if answer < 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": hexutil.Encode(output)})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestQuorumLatestRoundData(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []int64 // one provider each; negative fails
+		quorum  int
+		want    int64
+		wantErr bool
+	}{
+		{"all agree", []int64{100, 100, 100}, 3, 100, false},
+		{"majority agrees", []int64{100, 101, 100}, 2, 100, false},
+		{"one failure tolerated", []int64{100, -1, 100}, 2, 100, false},
+		{"quorum of one", []int64{-1, 100}, 1, 100, false},
+		{"disagreement", []int64{100, 101, 102}, 2, 0, true},
+		{"too many failures", []int64{100, -1, -1}, 2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := make([]*ethclient.Client, len(tt.answers))
+			for i, answer := range tt.answers {
+				clients[i] = oracleNode(t, answer)
+			}
+
+			r, err := NewReader(clients[0], common.HexToAddress("0x1"))
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+			if err := r.EnableQuorum(clients, tt.quorum); err != nil {
+				t.Fatalf("EnableQuorum: %v", err)
+			}
+
+			_, answer, _, _, _, err := r.GetLatestRoundData(context.Background())
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoQuorum) {
+					t.Fatalf("GetLatestRoundData error = %v, want ErrNoQuorum", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLatestRoundData: %v", err)
+			}
+			if answer.Int64() != tt.want {
+				t.Errorf("answer = %s, want %d", answer, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnableQuorumValidation(t *testing.T) {
+	client := oracleNode(t, 100)
+	r, err := NewReader(client, common.HexToAddress("0x1"))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+
+	for _, quorum := range []int{0, 3} {
+		if err := r.EnableQuorum([]*ethclient.Client{client, client}, quorum); err == nil {
+			t.Errorf("EnableQuorum with quorum %d of 2 succeeded, want an error", quorum)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/114windd/oracle-client/internal/rpc"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Classifier reports whether a failed attempt may succeed if repeated
+type Classifier func(err error) bool
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that IsRetryable rejects it. Do returns the
+// original error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func unwrapPermanent(err error) error {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return perm.err
+	}
+	return err
+}
+
+// sendError marks a failure to broadcast a transaction
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string { return e.err.Error() }
+func (e *sendError) Unwrap() error { return e.err }
+
+// SendFailed wraps an error returned while broadcasting a signed
+// transaction, so IsTxRetryable can tell that the node may have accepted it
+func SendFailed(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &sendError{err: err}
+}
+
+// JSON-RPC error codes that describe the request rather than the node's state
+const (
+	codeExecutionReverted = 3
+	codeInvalidRequest    = -32600
+	codeMethodNotFound    = -32601
+	codeInvalidParams     = -32602
+)
+
+// Node error messages that repeating the same transaction cannot fix
+var permanentMessages = []string{
+	"execution reverted",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"invalid sender",
+	"transaction type not supported",
+	"max fee per gas less than block base fee",
+}
+
+// IsRetryable is the default classifier. Errors marked Permanent, contract
+// reverts, calls to addresses without code or behind an open circuit
+// breaker, malformed JSON-RPC requests, 4xx HTTP responses other than 408 and
+// 429, and transactions the node rejects for good all fail fast. Anything
+// else, such as timeouts, connection failures and 5xx responses, is retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+
+	// An attempt timeout is transient; the caller's own deadline is checked
+	// by Do before the classifier runs
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, bind.ErrNoCode) || errors.Is(err, rpc.ErrCircuitOpen) {
+		return false
+	}
+
+	var httpErr gethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		code := httpErr.StatusCode
+		if code >= 400 && code < 500 {
+			return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+		}
+		return true
+	}
+
+	var rpcErr gethrpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case codeExecutionReverted, codeInvalidRequest, codeMethodNotFound, codeInvalidParams:
+			return false
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, permanent := range permanentMessages {
+		if strings.Contains(msg, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAmbiguous reports whether err is a broadcast failure without a definite
+// answer from the node, such as a timeout, a dropped connection or a 5xx
+// response. The node may have accepted the transaction regardless.
+func IsAmbiguous(err error) bool {
+	var send *sendError
+	if !errors.As(err, &send) {
+		return false
+	}
+
+	var httpErr gethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	var rpcErr gethrpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// IsTxRetryable classifies transaction submissions. An ambiguous broadcast
+// may still have delivered the transaction; repeating it would sign a second
+// update with the next nonce, so it fails fast. A broadcast is only repeated
+// when the node answered with a JSON-RPC error IsRetryable accepts, or with
+// 429. Failures before the broadcast are classified by IsRetryable.
+func IsTxRetryable(err error) bool {
+	var send *sendError
+	if !errors.As(err, &send) {
+		return IsRetryable(err)
+	}
+	if IsAmbiguous(err) {
+		return false
+	}
+
+	var httpErr gethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	return IsRetryable(err)
+}
@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/114windd/oracle-client/internal/rpc"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcError is a JSON-RPC error response
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"permanent", Permanent(errors.New("bad input")), false},
+		{"attempt timeout", fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		{"cancelled", context.Canceled, false},
+		{"no code", bind.ErrNoCode, false},
+		{"circuit open", rpc.ErrCircuitOpen, false},
+		{"http 408", gethrpc.HTTPError{StatusCode: 408}, true},
+		{"http 429", gethrpc.HTTPError{StatusCode: 429}, true},
+		{"http 401", gethrpc.HTTPError{StatusCode: 401}, false},
+		{"http 503", gethrpc.HTTPError{StatusCode: 503}, true},
+		{"reverted", rpcError{code: 3, msg: "execution reverted"}, false},
+		{"invalid params", rpcError{code: -32602, msg: "invalid argument 0"}, false},
+		{"server error", rpcError{code: -32000, msg: "header not found"}, true},
+		{"insufficient funds", errors.New("insufficient funds for gas * price + value"), false},
+		{"connection refused", errors.New("dial tcp: connection refused"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTxRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"estimate timeout", context.DeadlineExceeded, true},
+		{"estimate reverted", rpcError{code: 3, msg: "execution reverted"}, false},
+		{"send timeout", SendFailed(context.DeadlineExceeded), false},
+		{"send connection reset", SendFailed(errors.New("read: connection reset by peer")), false},
+		{"send http 503", SendFailed(gethrpc.HTTPError{StatusCode: 503}), false},
+		{"send http 429", SendFailed(gethrpc.HTTPError{StatusCode: 429}), true},
+		{"send server error", SendFailed(rpcError{code: -32000, msg: "txpool is full"}), true},
+		{"send insufficient funds", SendFailed(rpcError{code: -32000, msg: "insufficient funds"}), false},
+		{"send wrapped", fmt.Errorf("update: %w", SendFailed(rpcError{code: -32000, msg: "busy"})), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTxRetryable(tt.err); got != tt.want {
+				t.Errorf("IsTxRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAmbiguous(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not a broadcast", context.DeadlineExceeded, false},
+		{"send timeout", SendFailed(context.DeadlineExceeded), true},
+		{"send connection reset", SendFailed(errors.New("read: connection reset by peer")), true},
+		{"send http 502", SendFailed(gethrpc.HTTPError{StatusCode: 502}), true},
+		{"send http 429", SendFailed(gethrpc.HTTPError{StatusCode: 429}), false},
+		{"send rejected", SendFailed(rpcError{code: -32000, msg: "nonce too low"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAmbiguous(tt.err); got != tt.want {
+				t.Errorf("IsAmbiguous(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
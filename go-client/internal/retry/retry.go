@@ -3,50 +3,134 @@ package retry
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"math"
+	"math/rand/v2"
 	"time"
+
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Retry executes a function with exponential backoff retry logic
-func Retry(ctx context.Context, fn func() error) error {
-	const maxAttempts = 3
-	const baseDelay = 100 * time.Millisecond
-	const maxDelay = 5 * time.Second
-	const multiplier = 2.0
+// Policy describes how an operation is retried. Delays grow exponentially
+// from BaseDelay up to MaxDelay, and each actual delay is drawn uniformly from
+// zero to that bound ("full jitter") so clients do not retry in lockstep.
+type Policy struct {
+	// MaxAttempts is the number of attempts, including the first
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Multiplier  float64
+	// AttemptTimeout bounds each attempt; zero leaves only the caller's deadline
+	AttemptTimeout time.Duration
+	// Retryable decides whether a failed attempt is worth repeating. Nil
+	// means IsRetryable.
+	Retryable Classifier
+}
+
+// DefaultPolicy returns the policy used by Retry
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Multiplier:  2,
+		Retryable:   IsRetryable,
+	}
+}
+
+// Retry executes fn with the default policy
+func Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return DefaultPolicy().Do(ctx, fn)
+}
+
+// Do executes fn until it succeeds, fails with an error the classifier
+// rejects, or runs out of attempts. Each attempt runs in its own span, and fn
+// receives a context carrying it and the attempt timeout.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	maxAttempts := max(p.MaxAttempts, 1)
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
 
 	var lastErr error
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		select {
 		case <-ctx.Done():
+			metrics.RetryCalls.WithLabelValues("cancelled").Inc()
 			return ctx.Err()
 		default:
 		}
 
-		err := fn()
+		metrics.RetryAttempts.Inc()
+		err := p.attempt(ctx, attempt, fn)
 		if err == nil {
+			metrics.RetryCalls.WithLabelValues("success").Inc()
 			return nil
 		}
 
+		// The caller's context ending is not a failure of the operation
+		if ctx.Err() != nil {
+			metrics.RetryCalls.WithLabelValues("cancelled").Inc()
+			return err
+		}
+
+		if !retryable(err) {
+			metrics.RetryCalls.WithLabelValues("permanent").Inc()
+			slog.WarnContext(ctx, "retry attempt failed permanently", "attempt", attempt+1, "err", err)
+			return unwrapPermanent(err)
+		}
+
 		lastErr = err
+		slog.WarnContext(ctx, "retry attempt failed", "attempt", attempt+1, "max_attempts", maxAttempts, "err", err)
 
 		// Don't sleep on the last attempt
 		if attempt == maxAttempts-1 {
 			break
 		}
 
-		// Calculate delay with exponential backoff
-		delay := time.Duration(float64(baseDelay) * math.Pow(multiplier, float64(attempt)))
-		if delay > maxDelay {
-			delay = maxDelay
-		}
-
 		select {
 		case <-ctx.Done():
+			metrics.RetryCalls.WithLabelValues("cancelled").Inc()
 			return ctx.Err()
-		case <-time.After(delay):
+		case <-time.After(p.delay(attempt)):
 		}
 	}
 
+	metrics.RetryCalls.WithLabelValues("exhausted").Inc()
 	return fmt.Errorf("retry failed after %d attempts: %w", maxAttempts, lastErr)
 }
+
+// attempt runs fn once in its own span, bounded by the attempt timeout
+func (p Policy) attempt(ctx context.Context, attempt int, fn func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "retry.attempt", attribute.Int("retry.attempt", attempt+1))
+	if p.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		defer cancel()
+	}
+
+	err := fn(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// delay returns the jittered wait before the attempt after the given one
+func (p Policy) delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	bound := float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt))
+	if p.MaxDelay > 0 && bound > float64(p.MaxDelay) {
+		bound = float64(p.MaxDelay)
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(bound) + 1))
+}
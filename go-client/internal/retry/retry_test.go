@@ -0,0 +1,92 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		bound   time.Duration
+	}{
+		{"first", Policy{BaseDelay: 100 * time.Millisecond, Multiplier: 2}, 0, 100 * time.Millisecond},
+		{"grows", Policy{BaseDelay: 100 * time.Millisecond, Multiplier: 2}, 3, 800 * time.Millisecond},
+		{"capped", Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}, 10, time.Second},
+		{"default multiplier", Policy{BaseDelay: 10 * time.Millisecond}, 2, 40 * time.Millisecond},
+		{"no base delay", Policy{}, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if d := tt.policy.delay(tt.attempt); d < 0 || d > tt.bound {
+					t.Fatalf("delay(%d) = %s, want within [0, %s]", tt.attempt, d, tt.bound)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyDo(t *testing.T) {
+	transient := errors.New("connection refused")
+	fatal := errors.New("bad input")
+
+	tests := []struct {
+		name     string
+		errs     []error // returned by successive attempts; nil afterwards
+		attempts int
+		wantErr  error
+		wantRuns int
+	}{
+		{"succeeds first", nil, 3, nil, 1},
+		{"succeeds after retries", []error{transient, transient}, 3, nil, 3},
+		{"exhausted", []error{transient, transient, transient}, 3, transient, 3},
+		{"permanent stops", []error{Permanent(fatal)}, 3, fatal, 1},
+		{"single attempt", []error{transient}, 0, transient, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := Policy{MaxAttempts: tt.attempts, BaseDelay: time.Microsecond}
+			runs := 0
+			err := policy.Do(context.Background(), func(ctx context.Context) error {
+				runs++
+				if runs <= len(tt.errs) {
+					return tt.errs[runs-1]
+				}
+				return nil
+			})
+
+			if runs != tt.wantRuns {
+				t.Errorf("ran %d attempts, want %d", runs, tt.wantRuns)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Do() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() = %v, want %v", err, tt.wantErr)
+			}
+			var perm *permanentError
+			if errors.As(err, &perm) {
+				t.Errorf("Do() returned the Permanent wrapper: %v", err)
+			}
+		})
+	}
+}
+
+func TestPolicyDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runs := 0
+	err := Policy{MaxAttempts: 3}.Do(ctx, func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || runs != 0 {
+		t.Errorf("Do() = %v after %d attempts, want context.Canceled after none", err, runs)
+	}
+}
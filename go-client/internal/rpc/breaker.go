@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/metrics"
+)
+
+// ErrCircuitOpen is returned instead of calling an endpoint whose breaker is open
+var ErrCircuitOpen = errors.New("rpc circuit breaker open")
+
+// Breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// BreakerConfig configures a circuit breaker
+type BreakerConfig struct {
+	// FailureThreshold consecutive failures open the breaker; zero disables it
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting probes through
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many requests may probe a half-open breaker at
+	// once, and how many must succeed in a row to close it again
+	HalfOpenProbes int
+}
+
+// BreakerStatus is a snapshot of a breaker
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+}
+
+// Breaker is a closed/open/half-open circuit breaker. While closed every
+// request passes; FailureThreshold consecutive failures open it. While open
+// requests fail with ErrCircuitOpen until OpenTimeout has passed, then it is
+// half-open and lets HalfOpenProbes requests through: a failure opens it
+// again, and as many successes close it.
+type Breaker struct {
+	chain    string
+	provider string
+	cfg      BreakerConfig
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+}
+
+// NewBreaker creates a closed breaker for one provider of a chain
+func NewBreaker(chain, provider string, cfg BreakerConfig) *Breaker {
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+
+	b := &Breaker{chain: chain, provider: provider, cfg: cfg, state: StateClosed}
+	metrics.BreakerState.WithLabelValues(chain, provider).Set(0)
+	return b
+}
+
+// Allow reports whether a request may proceed. Every allowed request must be
+// followed by exactly one call to Record.
+func (b *Breaker) Allow() error {
+	if b == nil || b.cfg.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		b.transition(StateHalfOpen)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.inFlight >= b.cfg.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed request
+func (b *Breaker) Record(failed bool) {
+	if b == nil || b.cfg.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+
+	case StateHalfOpen:
+		// Probes from an earlier half-open period may still report back
+		if b.inFlight > 0 {
+			b.inFlight--
+		}
+		if failed {
+			b.failures++
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.failures = 0
+			b.transition(StateClosed)
+		}
+
+	case StateOpen:
+		// A request allowed before the breaker opened
+		if failed {
+			b.failures++
+		}
+	}
+}
+
+// Status returns a snapshot of the breaker. An open breaker whose timeout
+// has passed is reported as half-open.
+func (b *Breaker) Status() BreakerStatus {
+	if b == nil || b.cfg.FailureThreshold <= 0 {
+		return BreakerStatus{State: StateClosed}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		status.State = StateHalfOpen
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// open moves the breaker to the open state. Must be called with mu held.
+func (b *Breaker) open() {
+	b.openedAt = time.Now()
+	b.transition(StateOpen)
+}
+
+// transition changes state and resets the half-open bookkeeping. Must be
+// called with mu held.
+func (b *Breaker) transition(state string) {
+	if b.state != state {
+		slog.Warn("rpc circuit breaker state changed", "chain", b.chain, "provider", b.provider, "from", b.state, "to", state, "failures", b.failures)
+	}
+	b.state = state
+	b.inFlight = 0
+	b.successes = 0
+
+	var value float64
+	switch state {
+	case StateHalfOpen:
+		value = 1
+	case StateOpen:
+		value = 2
+	}
+	metrics.BreakerState.WithLabelValues(b.chain, b.provider).Set(value)
+}
@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// breakerStep is one action against a breaker and the state it leaves
+type breakerStep struct {
+	action string // "allow", "ok", "fail" or "expire"
+	denied bool   // for "allow": whether ErrCircuitOpen is expected
+	state  string
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   BreakerConfig
+		steps []breakerStep
+	}{
+		{
+			name: "opens after consecutive failures",
+			cfg:  BreakerConfig{FailureThreshold: 3},
+			steps: []breakerStep{
+				{action: "fail", state: StateClosed},
+				{action: "fail", state: StateClosed},
+				{action: "fail", state: StateOpen},
+				{action: "allow", denied: true, state: StateOpen},
+			},
+		},
+		{
+			name: "success resets the failure count",
+			cfg:  BreakerConfig{FailureThreshold: 2},
+			steps: []breakerStep{
+				{action: "fail", state: StateClosed},
+				{action: "ok", state: StateClosed},
+				{action: "fail", state: StateClosed},
+				{action: "fail", state: StateOpen},
+			},
+		},
+		{
+			name: "half-open probe closes it",
+			cfg:  BreakerConfig{FailureThreshold: 1},
+			steps: []breakerStep{
+				{action: "fail", state: StateOpen},
+				{action: "expire", state: StateHalfOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "allow", denied: true, state: StateHalfOpen},
+				{action: "ok", state: StateClosed},
+				{action: "allow", state: StateClosed},
+			},
+		},
+		{
+			name: "half-open probe failure reopens it",
+			cfg:  BreakerConfig{FailureThreshold: 1},
+			steps: []breakerStep{
+				{action: "fail", state: StateOpen},
+				{action: "expire", state: StateHalfOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "fail", state: StateOpen},
+				{action: "allow", denied: true, state: StateOpen},
+			},
+		},
+		{
+			name: "several probes must all succeed",
+			cfg:  BreakerConfig{FailureThreshold: 1, HalfOpenProbes: 2},
+			steps: []breakerStep{
+				{action: "fail", state: StateOpen},
+				{action: "expire", state: StateHalfOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "allow", state: StateHalfOpen},
+				{action: "allow", denied: true, state: StateHalfOpen},
+				{action: "ok", state: StateHalfOpen},
+				{action: "ok", state: StateClosed},
+			},
+		},
+		{
+			name: "disabled breaker never opens",
+			cfg:  BreakerConfig{},
+			steps: []breakerStep{
+				{action: "fail", state: StateClosed},
+				{action: "fail", state: StateClosed},
+				{action: "allow", state: StateClosed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker("test", "provider", tt.cfg)
+			for i, step := range tt.steps {
+				switch step.action {
+				case "allow":
+					err := b.Allow()
+					if denied := errors.Is(err, ErrCircuitOpen); denied != step.denied {
+						t.Fatalf("step %d: Allow() = %v, want denied %v", i, err, step.denied)
+					}
+				case "ok", "fail":
+					b.Record(step.action == "fail")
+				case "expire":
+					b.mu.Lock()
+					b.openedAt = time.Now().Add(-b.cfg.OpenTimeout)
+					b.mu.Unlock()
+				}
+				if got := b.Status().State; got != step.state {
+					t.Fatalf("step %d (%s): state = %s, want %s", i, step.action, got, step.state)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerStatus(t *testing.T) {
+	b := NewBreaker("test", "provider", BreakerConfig{FailureThreshold: 2})
+	if status := b.Status(); status.OpenedAt != nil || status.ConsecutiveFailures != 0 {
+		t.Errorf("new breaker status = %+v", status)
+	}
+
+	b.Record(true)
+	b.Record(true)
+	status := b.Status()
+	if status.State != StateOpen || status.ConsecutiveFailures != 2 || status.OpenedAt == nil {
+		t.Errorf("open breaker status = %+v", status)
+	}
+
+	var nilBreaker *Breaker
+	if err := nilBreaker.Allow(); err != nil {
+		t.Errorf("nil breaker Allow() = %v", err)
+	}
+	nilBreaker.Record(true)
+	if state := nilBreaker.Status().State; state != StateClosed {
+		t.Errorf("nil breaker state = %s", state)
+	}
+}
@@ -0,0 +1,416 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/ethereum/go-ethereum/ethclient"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Endpoint is one RPC provider of a chain
+type Endpoint struct {
+	Name     string
+	URL      string
+	Priority int // lower is preferred
+}
+
+// ParseEndpoints parses comma-separated RPC URLs. Each may end with
+// ";priority=<n>" and ";name=<name>" options. Without a priority endpoints
+// are preferred in the order listed, and without a name one is taken from
+// the URL's host, so credentials in the URL never show up in status output.
+func ParseEndpoints(value string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	seen := make(map[string]int)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		rawURL, options, _ := strings.Cut(entry, ";")
+		parsed, err := url.Parse(rawURL)
+		if err != nil || parsed.Scheme == "" {
+			return nil, fmt.Errorf("invalid RPC URL %q", redact(rawURL))
+		}
+		endpoint := Endpoint{Name: parsed.Hostname(), URL: rawURL, Priority: len(endpoints)}
+		if endpoint.Name == "" {
+			endpoint.Name = parsed.Scheme
+		}
+
+		for _, option := range strings.Split(options, ";") {
+			if option == "" {
+				continue
+			}
+			key, val, _ := strings.Cut(option, "=")
+			switch key {
+			case "priority":
+				priority, err := strconv.Atoi(val)
+				if err != nil {
+					return nil, fmt.Errorf("invalid priority for RPC endpoint %q: %w", endpoint.Name, err)
+				}
+				endpoint.Priority = priority
+			case "name":
+				if val == "" {
+					return nil, fmt.Errorf("empty name for RPC endpoint %q", endpoint.Name)
+				}
+				endpoint.Name = val
+			default:
+				return nil, fmt.Errorf("unknown option %q for RPC endpoint %q", option, endpoint.Name)
+			}
+		}
+
+		// Providers on the same host still need distinct names
+		seen[endpoint.Name]++
+		if n := seen[endpoint.Name]; n > 1 {
+			endpoint.Name = fmt.Sprintf("%s-%d", endpoint.Name, n)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, errors.New("no RPC endpoints configured")
+	}
+	return endpoints, nil
+}
+
+// redact strips credentials and the path, which often carries an API key,
+// from an RPC URL
+func redact(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "<invalid>"
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// PoolConfig configures a provider pool
+type PoolConfig struct {
+	Breaker BreakerConfig
+	// MaxBlockLag is how many blocks a provider may fall behind the highest
+	// head in the pool before it is demoted; zero disables the check
+	MaxBlockLag uint64
+	// CheckInterval is how often every provider's head block is polled
+	CheckInterval time.Duration
+}
+
+// ProviderStatus is a snapshot of one provider of a pool
+type ProviderStatus struct {
+	Name        string        `json:"name"`
+	Priority    int           `json:"priority"`
+	Healthy     bool          `json:"healthy"`
+	Score       float64       `json:"score"`
+	LatencyMs   int64         `json:"latencyMs"`
+	BlockNumber uint64        `json:"blockNumber"`
+	BlockLag    uint64        `json:"blockLag"`
+	Lagging     bool          `json:"lagging"`
+	CheckedAt   *time.Time    `json:"checkedAt,omitempty"`
+	LastError   string        `json:"lastError,omitempty"`
+	Breaker     BreakerStatus `json:"breaker"`
+}
+
+// Pool spreads a chain's RPC traffic over several providers. Requests go to
+// the preferred healthy provider and fail over to the next one when it
+// errors, throttles or its breaker is open. Providers that fall behind on
+// block height are only used once every other provider has failed.
+type Pool struct {
+	chain     string
+	cfg       PoolConfig
+	providers []*provider
+	client    *ethclient.Client
+}
+
+// NewPool dials every endpoint of the chain, each of which must be HTTP(S)
+func NewPool(ctx context.Context, chain string, endpoints []Endpoint, cfg PoolConfig) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no RPC endpoints configured for chain %q", chain)
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 15 * time.Second
+	}
+
+	pool := &Pool{chain: chain, cfg: cfg}
+
+	for _, endpoint := range endpoints {
+		if !IsHTTP(endpoint.URL) {
+			return nil, fmt.Errorf("RPC endpoint %q of chain %q: only HTTP endpoints are supported", endpoint.Name, chain)
+		}
+		parsed, err := url.Parse(endpoint.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for RPC endpoint %q: %w", endpoint.Name, err)
+		}
+
+		p := &provider{
+			chain:    chain,
+			name:     endpoint.Name,
+			priority: endpoint.Priority,
+			url:      parsed,
+			breaker:  NewBreaker(chain, endpoint.Name, cfg.Breaker),
+			next:     http.DefaultTransport,
+			score:    1,
+		}
+		rpcClient, err := gethrpc.DialOptions(ctx, endpoint.URL, gethrpc.WithHTTPClient(&http.Client{Transport: p}))
+		if err != nil {
+			return nil, err
+		}
+		p.client = ethclient.NewClient(rpcClient)
+		pool.providers = append(pool.providers, p)
+	}
+	sort.SliceStable(pool.providers, func(i, j int) bool {
+		return pool.providers[i].priority < pool.providers[j].priority
+	})
+
+	httpClient := &http.Client{Transport: &failoverTransport{pool: pool}}
+	rpcClient, err := gethrpc.DialOptions(ctx, endpoints[0].URL, gethrpc.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, err
+	}
+	pool.client = ethclient.NewClient(rpcClient)
+	return pool, nil
+}
+
+// IsHTTP reports whether rawURL is an HTTP(S) endpoint. Requests to other
+// transports cannot pass through a breaker or fail over, so the pool
+// accepts HTTP endpoints only.
+func IsHTTP(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
+
+// Client returns the client that fails over between the pool's providers
+func (p *Pool) Client() *ethclient.Client {
+	return p.client
+}
+
+// ProviderClients returns a client per provider, each talking to that
+// provider alone, in priority order
+func (p *Pool) ProviderClients() []*ethclient.Client {
+	clients := make([]*ethclient.Client, 0, len(p.providers))
+	for _, prov := range p.providers {
+		clients = append(clients, prov.client)
+	}
+	return clients
+}
+
+// Close closes every client of the pool
+func (p *Pool) Close() {
+	for _, prov := range p.providers {
+		if prov.client != p.client {
+			prov.client.Close()
+		}
+	}
+	p.client.Close()
+}
+
+// Status returns a snapshot of every provider in priority order
+func (p *Pool) Status() []ProviderStatus {
+	statuses := make([]ProviderStatus, 0, len(p.providers))
+	for _, prov := range p.providers {
+		breaker := prov.breaker.Status()
+
+		prov.mu.Lock()
+		status := ProviderStatus{
+			Name:        prov.name,
+			Priority:    prov.priority,
+			Healthy:     breaker.State == StateClosed && !prov.lagging,
+			Score:       prov.score,
+			LatencyMs:   prov.latency.Milliseconds(),
+			BlockNumber: prov.blockNumber,
+			BlockLag:    prov.blockLag,
+			Lagging:     prov.lagging,
+			LastError:   prov.lastErr,
+			Breaker:     breaker,
+		}
+		if !prov.checkedAt.IsZero() {
+			checkedAt := prov.checkedAt
+			status.CheckedAt = &checkedAt
+		}
+		prov.mu.Unlock()
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Run polls every provider's head block each CheckInterval and demotes the
+// ones lagging behind the pool, until ctx is cancelled
+func (p *Pool) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.checkHeads(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHeads fetches every provider's head block and updates their lag
+func (p *Pool) checkHeads(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.CheckInterval)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, prov := range p.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			blockNumber, err := prov.client.BlockNumber(ctx)
+			prov.mu.Lock()
+			defer prov.mu.Unlock()
+			prov.checkedAt = time.Now()
+			if err != nil {
+				prov.lastErr = prov.redactError(err.Error())
+				return
+			}
+			prov.blockNumber = blockNumber
+		}()
+	}
+	wg.Wait()
+
+	var highest uint64
+	for _, prov := range p.providers {
+		prov.mu.Lock()
+		highest = max(highest, prov.blockNumber)
+		prov.mu.Unlock()
+	}
+
+	for _, prov := range p.providers {
+		prov.mu.Lock()
+		prov.blockLag = highest - prov.blockNumber
+		lagging := p.cfg.MaxBlockLag > 0 && prov.blockLag > p.cfg.MaxBlockLag
+		if lagging != prov.lagging {
+			if lagging {
+				slog.WarnContext(ctx, "rpc provider fell behind", "chain", p.chain, "provider", prov.name, "block", prov.blockNumber, "lag", prov.blockLag)
+			} else {
+				slog.InfoContext(ctx, "rpc provider caught up", "chain", p.chain, "provider", prov.name, "block", prov.blockNumber)
+			}
+		}
+		prov.lagging = lagging
+		metrics.RPCProviderBlockLag.WithLabelValues(p.chain, prov.name).Set(float64(prov.blockLag))
+		prov.mu.Unlock()
+	}
+}
+
+// sorted returns the providers in the order requests should try them:
+// providers that are neither lagging nor behind a tripped breaker first,
+// each group by priority and then by score
+func (p *Pool) sorted() []*provider {
+	type candidate struct {
+		prov     *provider
+		degraded bool
+		score    float64
+	}
+
+	candidates := make([]candidate, 0, len(p.providers))
+	for _, prov := range p.providers {
+		degraded := prov.breaker.Status().State != StateClosed
+		prov.mu.Lock()
+		degraded = degraded || prov.lagging
+		score := prov.score
+		prov.mu.Unlock()
+		candidates = append(candidates, candidate{prov: prov, degraded: degraded, score: score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.degraded != b.degraded {
+			return !a.degraded
+		}
+		if a.prov.priority != b.prov.priority {
+			return a.prov.priority < b.prov.priority
+		}
+		return a.score > b.score
+	})
+
+	providers := make([]*provider, len(candidates))
+	for i, c := range candidates {
+		providers[i] = c.prov
+	}
+	return providers
+}
+
+// failoverTransport sends each request to the pool's providers in turn
+// until one answers without a transport error, throttling or server error
+type failoverTransport struct {
+	pool *Pool
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		lastResp *http.Response
+		lastErr  error
+	)
+
+	for i, prov := range t.pool.sorted() {
+		attempt := req
+		if i > 0 {
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			attempt = req.Clone(req.Context())
+			attempt.Body = body
+			metrics.RPCFailovers.WithLabelValues(t.pool.chain).Inc()
+		}
+
+		resp, err := prov.RoundTrip(attempt)
+		if err == nil && !failedStatus(resp.StatusCode) {
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			if resp != nil {
+				return resp, nil
+			}
+			return nil, err
+		}
+
+		if err != nil {
+			// An open breaker is the least informative failure to report
+			if lastErr == nil || !errors.Is(err, ErrCircuitOpen) {
+				lastErr = err
+			}
+			if !errors.Is(err, ErrCircuitOpen) {
+				slog.WarnContext(req.Context(), "rpc provider failed", "chain", t.pool.chain, "provider", prov.name, "err", err)
+			}
+		} else {
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			lastResp = resp
+			slog.WarnContext(req.Context(), "rpc provider failed", "chain", t.pool.chain, "provider", prov.name, "status", resp.StatusCode)
+		}
+	}
+
+	if lastResp != nil {
+		return lastResp, nil
+	}
+	if lastErr == nil {
+		lastErr = ErrCircuitOpen
+	}
+	return nil, lastErr
+}
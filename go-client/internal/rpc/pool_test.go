@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []Endpoint
+		wantErr bool
+	}{
+		{
+			name:  "listed order is the priority",
+			value: "https://a.example/key, https://b.example",
+			want: []Endpoint{
+				{Name: "a.example", URL: "https://a.example/key", Priority: 0},
+				{Name: "b.example", URL: "https://b.example", Priority: 1},
+			},
+		},
+		{
+			name:  "options",
+			value: "https://a.example;priority=5;name=main",
+			want:  []Endpoint{{Name: "main", URL: "https://a.example", Priority: 5}},
+		},
+		{
+			name:  "same host gets distinct names",
+			value: "https://a.example/1,https://a.example/2",
+			want: []Endpoint{
+				{Name: "a.example", URL: "https://a.example/1", Priority: 0},
+				{Name: "a.example-2", URL: "https://a.example/2", Priority: 1},
+			},
+		},
+		{name: "empty", value: " , ", wantErr: true},
+		{name: "no scheme", value: "a.example", wantErr: true},
+		{name: "bad priority", value: "https://a.example;priority=x", wantErr: true},
+		{name: "unknown option", value: "https://a.example;weight=2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEndpoints(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseEndpoints(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEndpoints(%q): %v", tt.value, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseEndpoints(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("endpoint %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProviderObserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []bool // true for a failure
+		want     float64
+	}{
+		{"success keeps a full score", []bool{false, false}, 1},
+		{"failure decays the score", []bool{true}, 0.8},
+		{"failures compound", []bool{true, true}, 0.64},
+		{"success recovers", []bool{true, false}, 0.84},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &provider{score: 1}
+			for _, failed := range tt.outcomes {
+				if failed {
+					p.observe("connection refused", 0)
+				} else {
+					p.observe("", 10*time.Millisecond)
+				}
+			}
+			if diff := p.score - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("score = %v, want %v", p.score, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolSorted(t *testing.T) {
+	openBreaker := NewBreaker("test", "open", BreakerConfig{FailureThreshold: 1})
+	openBreaker.Record(true)
+
+	tests := []struct {
+		name      string
+		providers []*provider
+		want      []string
+	}{
+		{
+			name: "priority first",
+			providers: []*provider{
+				{name: "b", priority: 1, score: 1},
+				{name: "a", priority: 0, score: 0.5},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "score breaks priority ties",
+			providers: []*provider{
+				{name: "low", score: 0.3},
+				{name: "high", score: 0.9},
+			},
+			want: []string{"high", "low"},
+		},
+		{
+			name: "lagging providers go last",
+			providers: []*provider{
+				{name: "behind", priority: 0, score: 1, lagging: true},
+				{name: "synced", priority: 1, score: 0.1},
+			},
+			want: []string{"synced", "behind"},
+		},
+		{
+			name: "open breakers go last",
+			providers: []*provider{
+				{name: "open", priority: 0, score: 1, breaker: openBreaker},
+				{name: "closed", priority: 1, score: 1},
+			},
+			want: []string{"closed", "open"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &Pool{chain: "test", providers: tt.providers}
+			sorted := pool.sorted()
+			for i, prov := range sorted {
+				if prov.name != tt.want[i] {
+					t.Fatalf("order = %v, want %v", names(sorted), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func names(providers []*provider) []string {
+	out := make([]string, len(providers))
+	for i, prov := range providers {
+		out[i] = prov.name
+	}
+	return out
+}
+
+// node serves eth_blockNumber with the given HTTP status
+func node(t *testing.T, status int, block string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": block})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPoolFailover(t *testing.T) {
+	down := node(t, http.StatusServiceUnavailable, "")
+	up := node(t, http.StatusOK, "0x2a")
+
+	pool, err := NewPool(context.Background(), "test", []Endpoint{
+		{Name: "down", URL: down.URL, Priority: 0},
+		{Name: "up", URL: up.URL, Priority: 1},
+	}, PoolConfig{Breaker: BreakerConfig{FailureThreshold: 2}})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	defer pool.Close()
+
+	for range 2 {
+		block, err := pool.Client().BlockNumber(context.Background())
+		if err != nil {
+			t.Fatalf("BlockNumber: %v", err)
+		}
+		if block != 42 {
+			t.Fatalf("BlockNumber = %d, want 42", block)
+		}
+	}
+
+	status := make(map[string]ProviderStatus)
+	for _, s := range pool.Status() {
+		status[s.Name] = s
+	}
+	if s := status["down"]; s.Score >= 1 || s.Breaker.State != StateOpen || s.LastError == "" {
+		t.Errorf("failing provider status = %+v", s)
+	}
+	if s := status["up"]; s.Score != 1 || s.Breaker.State != StateClosed {
+		t.Errorf("healthy provider status = %+v", s)
+	}
+	if order := names(pool.sorted()); order[0] != "up" {
+		t.Errorf("order after failures = %v, want up first", order)
+	}
+}
+
+func TestNewPoolRejectsNonHTTP(t *testing.T) {
+	for _, url := range []string{"ws://localhost:8546", "wss://a.example", "/tmp/geth.ipc"} {
+		_, err := NewPool(context.Background(), "test", []Endpoint{{Name: "node", URL: url}}, PoolConfig{})
+		if err == nil {
+			t.Errorf("NewPool(%q) succeeded, want an error", url)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// provider is one RPC endpoint of a chain. Requests over HTTP are sent to its
+// URL, traced, timed and counted per JSON-RPC method, and pass through its
+// breaker; their outcomes feed its health score.
+type provider struct {
+	chain    string
+	name     string
+	priority int
+	url      *url.URL
+	breaker  *Breaker
+	next     http.RoundTripper
+
+	// client talks to this provider alone
+	client *ethclient.Client
+
+	mu          sync.Mutex
+	score       float64
+	latency     time.Duration
+	blockNumber uint64
+	blockLag    uint64
+	lagging     bool
+	checkedAt   time.Time
+	lastErr     string
+}
+
+// scoreWeight is how much the latest request moves a provider's score
+const scoreWeight = 0.2
+
+func (p *provider) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := requestMethod(req)
+	ctx, span := tracing.Start(req.Context(), "rpc "+method,
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", method),
+		attribute.String("chain", p.chain),
+		attribute.String("rpc.provider", p.name),
+	)
+
+	if err := p.breaker.Allow(); err != nil {
+		metrics.RPCErrors.WithLabelValues(p.chain, p.name, method).Inc()
+		tracing.End(span, err)
+		return nil, err
+	}
+	start := time.Now()
+
+	resp, err := p.next.RoundTrip(p.rewrite(req.WithContext(ctx)))
+	if err != nil {
+		// A caller giving up says nothing about the node
+		failed := !errors.Is(err, context.Canceled)
+		p.breaker.Record(failed)
+		if failed {
+			p.observe(err.Error(), 0)
+		}
+		metrics.RPCRequestDuration.WithLabelValues(p.chain, p.name, method).Observe(time.Since(start).Seconds())
+		metrics.RPCErrors.WithLabelValues(p.chain, p.name, method).Inc()
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	// Read the whole body so the latency covers it and JSON-RPC errors,
+	// which arrive with a 200 status, can be detected
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	metrics.RPCRequestDuration.WithLabelValues(p.chain, p.name, method).Observe(time.Since(start).Seconds())
+
+	// JSON-RPC errors such as reverts come from a healthy node, so only
+	// transport failures, throttling and server errors trip the breaker
+	failed := (err != nil && !errors.Is(err, context.Canceled)) || failedStatus(resp.StatusCode)
+	p.breaker.Record(failed)
+	switch {
+	case errors.Is(err, context.Canceled):
+	case err != nil:
+		p.observe(err.Error(), 0)
+	case failed:
+		p.observe(resp.Status, 0)
+	default:
+		p.observe("", time.Since(start))
+	}
+
+	if err != nil || resp.StatusCode != http.StatusOK || hasRPCError(body) {
+		metrics.RPCErrors.WithLabelValues(p.chain, p.name, method).Inc()
+		span.SetStatus(codes.Error, "request failed")
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// rewrite points req at the provider's URL, whichever endpoint the client
+// was dialled with
+func (p *provider) rewrite(req *http.Request) *http.Request {
+	out := req.Clone(req.Context())
+	target := *p.url
+	target.User = nil
+	out.URL = &target
+	out.Host = ""
+	if p.url.User != nil {
+		password, _ := p.url.User.Password()
+		out.SetBasicAuth(p.url.User.Username(), password)
+	}
+	return out
+}
+
+// observe folds the outcome of a request into the provider's score and
+// latency; errMsg is empty for a success
+func (p *provider) observe(errMsg string, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if errMsg != "" {
+		p.score = (1 - scoreWeight) * p.score
+		p.lastErr = p.redactError(errMsg)
+		return
+	}
+	p.score = (1-scoreWeight)*p.score + scoreWeight
+	if p.latency == 0 {
+		p.latency = latency
+	} else {
+		p.latency = time.Duration((1-scoreWeight)*float64(p.latency) + scoreWeight*float64(latency))
+	}
+}
+
+// redactError removes the provider's URL, which may carry credentials, from
+// an error message
+func (p *provider) redactError(msg string) string {
+	if p.url == nil {
+		return msg
+	}
+	return strings.ReplaceAll(msg, p.url.String(), redact(p.url.String()))
+}
+
+// failedStatus reports whether an HTTP status means the node, rather than
+// the request, is at fault
+func failedStatus(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// requestMethod returns the JSON-RPC method of req, or "batch" for batches
+func requestMethod(req *http.Request) string {
+	if req.GetBody == nil {
+		return "unknown"
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "unknown"
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "unknown"
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return "batch"
+	}
+
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Method == "" {
+		return "unknown"
+	}
+	return msg.Method
+}
+
+// hasRPCError reports whether a JSON-RPC response, or any response in a
+// batch, carries an error
+func hasRPCError(body []byte) bool {
+	type response struct {
+		Error json.RawMessage `json:"error"`
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var batch []response
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return true
+		}
+		for _, msg := range batch {
+			if len(msg.Error) > 0 && string(msg.Error) != "null" {
+				return true
+			}
+		}
+		return false
+	}
+
+	var msg response
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return true
+	}
+	return len(msg.Error) > 0 && string(msg.Error) != "null"
+}
@@ -0,0 +1,69 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KeySigner signs with a private key held in memory
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a signer from a hex private key, with or without 0x
+func NewKeySigner(privateKeyHex string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		// The error could echo part of the key
+		return nil, errors.New("invalid private key")
+	}
+	return newKeySigner(key), nil
+}
+
+// NewKeystoreSigner decrypts a go-ethereum JSON keystore file with the
+// password on the first line of passwordFile
+func NewKeystoreSigner(keystoreFile, passwordFile string) (*KeySigner, error) {
+	keyJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+	if passwordFile == "" {
+		return nil, errors.New("keystore needs a password file")
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore password: %w", err)
+	}
+
+	firstLine, _, _ := strings.Cut(string(password), "\n")
+	key, err := keystore.DecryptKey(keyJSON, strings.TrimSuffix(firstLine, "\r"))
+	if err != nil {
+		return nil, fmt.Errorf("unlock keystore %s: %w", keystoreFile, err)
+	}
+	return newKeySigner(key.PrivateKey), nil
+}
+
+func newKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// Address returns the key's account
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx with the key
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
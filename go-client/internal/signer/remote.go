@@ -0,0 +1,164 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/114windd/oracle-client/internal/tracing"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RemoteSigner delegates signing to an external signer, such as Clef, over
+// its account_signTransaction JSON-RPC method. The key never enters this
+// process.
+type RemoteSigner struct {
+	client  *gethrpc.Client
+	address common.Address
+}
+
+// SendTxArgs are the account_signTransaction arguments, as Clef defines them
+type SendTxArgs struct {
+	From                 common.MixedcaseAddress  `json:"from"`
+	To                   *common.MixedcaseAddress `json:"to"`
+	Gas                  hexutil.Uint64           `json:"gas"`
+	GasPrice             *hexutil.Big             `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big             `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big             `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big              `json:"value"`
+	Nonce                hexutil.Uint64           `json:"nonce"`
+	Data                 *hexutil.Bytes           `json:"data"`
+	ChainID              *hexutil.Big             `json:"chainId,omitempty"`
+}
+
+// SignTxResult is the account_signTransaction result
+type SignTxResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// Transaction builds the unsigned transaction args describe: EIP-1559 when
+// fee caps are given, legacy otherwise
+func (args SendTxArgs) Transaction() (*types.Transaction, error) {
+	if args.ChainID == nil {
+		return nil, errors.New("chainId is required")
+	}
+	var to *common.Address
+	if args.To != nil {
+		address := args.To.Address()
+		to = &address
+	}
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+
+	switch {
+	case args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas != nil:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   args.ChainID.ToInt(),
+			Nonce:     uint64(args.Nonce),
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        to,
+			Value:     args.Value.ToInt(),
+			Data:      data,
+		}), nil
+	case args.GasPrice != nil:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: args.GasPrice.ToInt(),
+			Gas:      uint64(args.Gas),
+			To:       to,
+			Value:    args.Value.ToInt(),
+			Data:     data,
+		}), nil
+	default:
+		return nil, errors.New("either gasPrice or maxFeePerGas and maxPriorityFeePerGas are required")
+	}
+}
+
+// NewRemoteSigner connects to the signer at rawURL (HTTP, WebSocket or IPC),
+// which must hold the key for address
+func NewRemoteSigner(ctx context.Context, rawURL string, address common.Address) (*RemoteSigner, error) {
+	if address == (common.Address{}) {
+		return nil, errors.New("remote signer needs the address to sign for")
+	}
+	client, err := gethrpc.DialContext(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("connect to remote signer: %w", err)
+	}
+	return &RemoteSigner{client: client, address: address}, nil
+}
+
+// Address returns the account the remote signer signs for
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx asks the remote signer to sign tx, and checks that what comes back
+// is tx, unchanged, signed by the expected account
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (_ *types.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "signer.signTransaction",
+		attribute.String("signer.address", s.address.Hex()),
+		attribute.Int64("tx.nonce", int64(tx.Nonce())),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	data := hexutil.Bytes(tx.Data())
+	args := SendTxArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if to := tx.To(); to != nil {
+		mixed := common.NewMixedcaseAddress(*to)
+		args.To = &mixed
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("remote signer: unsupported transaction type %d", tx.Type())
+	}
+
+	var result SignTxResult
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %w", err)
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New("remote signer returned a different transaction than requested")
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid signature: %w", err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("remote signer signed for %s instead of %s", from.Hex(), s.address.Hex())
+	}
+	return signed, nil
+}
+
+// Close disconnects from the remote signer
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
@@ -0,0 +1,190 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	chainID  = big.NewInt(31337)
+	feedAddr = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+)
+
+// clef answers account_signTransaction like cmd/devsigner does, after letting
+// the test tamper with the transaction it signs
+type clef struct {
+	signer *KeySigner
+	tamper func(tx *types.Transaction) (*types.Transaction, *KeySigner)
+}
+
+func (c *clef) SignTransaction(ctx context.Context, args SendTxArgs, methodSelector *string) (*SignTxResult, error) {
+	if args.From.Address() != c.signer.Address() {
+		return nil, errors.New("unknown account")
+	}
+	tx, err := args.Transaction()
+	if err != nil {
+		return nil, err
+	}
+
+	key := c.signer
+	if c.tamper != nil {
+		tx, key = c.tamper(tx)
+	}
+	signed, err := key.SignTx(ctx, tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &SignTxResult{Raw: raw, Tx: signed}, nil
+}
+
+func newKey(t *testing.T, hex string) *KeySigner {
+	t.Helper()
+	key, err := NewKeySigner(hex)
+	if err != nil {
+		t.Fatalf("NewKeySigner: %v", err)
+	}
+	return key
+}
+
+// newRemote starts the stand-in over HTTP and connects a RemoteSigner to it
+func newRemote(t *testing.T, c *clef) *RemoteSigner {
+	t.Helper()
+
+	server := gethrpc.NewServer()
+	if err := server.RegisterName("account", c); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+	t.Cleanup(server.Stop)
+
+	remote, err := NewRemoteSigner(context.Background(), srv.URL, c.signer.Address())
+	if err != nil {
+		t.Fatalf("NewRemoteSigner: %v", err)
+	}
+	t.Cleanup(remote.Close)
+	return remote
+}
+
+func legacyTx(nonce uint64) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(2_000_000_000),
+		Gas:      60_000,
+		To:       &feedAddr,
+		Data:     []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func dynamicFeeTx(nonce uint64) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(30_000_000_000),
+		Gas:       60_000,
+		To:        &feedAddr,
+		Value:     big.NewInt(1),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func TestRemoteSignerSignTx(t *testing.T) {
+	key := newKey(t, strings.Repeat("11", 32))
+	remote := newRemote(t, &clef{signer: key})
+
+	for _, tx := range []*types.Transaction{legacyTx(7), dynamicFeeTx(8)} {
+		signed, err := remote.SignTx(context.Background(), tx, chainID)
+		if err != nil {
+			t.Fatalf("SignTx type %d: %v", tx.Type(), err)
+		}
+
+		// The remote signature is the one the key would make locally
+		want, err := key.SignTx(context.Background(), tx, chainID)
+		if err != nil {
+			t.Fatalf("local SignTx: %v", err)
+		}
+		if signed.Hash() != want.Hash() {
+			t.Errorf("type %d: signed hash %s, want %s", tx.Type(), signed.Hash().Hex(), want.Hash().Hex())
+		}
+		if signed.Type() != tx.Type() || signed.Nonce() != tx.Nonce() {
+			t.Errorf("signed type %d nonce %d, want type %d nonce %d", signed.Type(), signed.Nonce(), tx.Type(), tx.Nonce())
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil || from != key.Address() {
+			t.Errorf("type %d: sender %s, %v, want %s", tx.Type(), from.Hex(), err, key.Address().Hex())
+		}
+	}
+}
+
+func TestRemoteSignerRejects(t *testing.T) {
+	key := newKey(t, strings.Repeat("11", 32))
+	other := newKey(t, strings.Repeat("22", 32))
+
+	tests := []struct {
+		name   string
+		tamper func(tx *types.Transaction) (*types.Transaction, *KeySigner)
+		error  string
+	}{
+		{
+			"different nonce",
+			func(tx *types.Transaction) (*types.Transaction, *KeySigner) {
+				return dynamicFeeTx(tx.Nonce() + 1), key
+			},
+			"different transaction",
+		},
+		{
+			"different recipient",
+			func(tx *types.Transaction) (*types.Transaction, *KeySigner) {
+				to := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+				return types.NewTx(&types.DynamicFeeTx{
+					ChainID: chainID, Nonce: tx.Nonce(), GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap(),
+					Gas: tx.Gas(), To: &to, Value: tx.Value(), Data: tx.Data(),
+				}), key
+			},
+			"different transaction",
+		},
+		{
+			"wrong sender",
+			func(tx *types.Transaction) (*types.Transaction, *KeySigner) {
+				return tx, other
+			},
+			"signed for " + other.Address().Hex(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newRemote(t, &clef{signer: key, tamper: tt.tamper})
+
+			signed, err := remote.SignTx(context.Background(), dynamicFeeTx(3), chainID)
+			if err == nil {
+				t.Fatalf("SignTx accepted %s", signed.Hash().Hex())
+			}
+			if !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("SignTx error = %v, want it to contain %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestRemoteSignerSignerError(t *testing.T) {
+	key := newKey(t, strings.Repeat("11", 32))
+	remote := newRemote(t, &clef{signer: newKey(t, strings.Repeat("22", 32))})
+	remote.address = key.Address()
+
+	if _, err := remote.SignTx(context.Background(), legacyTx(1), chainID); err == nil || !strings.Contains(err.Error(), "unknown account") {
+		t.Errorf("SignTx error = %v, want the signer's unknown account error", err)
+	}
+}
@@ -0,0 +1,57 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Signer signs transactions for one account
+type Signer interface {
+	// Address is the account transactions are signed for
+	Address() common.Address
+	// SignTx returns tx signed for chainID
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// Options selects and configures a signer. Exactly one of PrivateKey,
+// Keystore or URL must be set.
+type Options struct {
+	// PrivateKey is a hex secp256k1 key, with or without 0x
+	PrivateKey string
+
+	// Keystore is a go-ethereum encrypted JSON key file, unlocked with the
+	// first line of PasswordFile
+	Keystore     string
+	PasswordFile string
+
+	// URL is a remote signer speaking Clef's account_signTransaction, which
+	// signs for Address
+	URL     string
+	Address common.Address
+}
+
+// New creates the signer described by opts
+func New(ctx context.Context, opts Options) (Signer, error) {
+	set := 0
+	for _, value := range []string{opts.PrivateKey, opts.Keystore, opts.URL} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of a private key, a keystore or a remote signer URL must be configured")
+	}
+
+	switch {
+	case opts.PrivateKey != "":
+		return NewKeySigner(opts.PrivateKey)
+	case opts.Keystore != "":
+		return NewKeystoreSigner(opts.Keystore, opts.PasswordFile)
+	default:
+		return NewRemoteSigner(ctx, opts.URL, opts.Address)
+	}
+}
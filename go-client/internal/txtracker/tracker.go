@@ -0,0 +1,387 @@
+package txtracker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/events"
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Tracker follows update transactions on one chain until they are confirmed,
+// failed or dropped, and records every status change in Postgres. Status
+// changes are also published on the bus.
+type Tracker struct {
+	client        *ethclient.Client
+	db            *db.DB
+	bus           *events.Bus
+	chain         string
+	confirmations uint64
+	pollInterval  time.Duration
+	dropTimeout   time.Duration
+}
+
+// New creates a new tracker. A transaction is confirmed once its block has
+// the given number of confirmations, counting the block itself.
+func New(client *ethclient.Client, database *db.DB, bus *events.Bus, chain string, confirmations uint64, pollInterval, dropTimeout time.Duration) *Tracker {
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+
+	return &Tracker{
+		client:        client,
+		db:            database,
+		bus:           bus,
+		chain:         chain,
+		confirmations: confirmations,
+		pollInterval:  pollInterval,
+		dropTimeout:   dropTimeout,
+	}
+}
+
+// Track records a freshly broadcast transaction as pending. sentAtBlock is
+// the chain head when it was sent, used to decide when it is stuck.
+func (t *Tracker) Track(ctx context.Context, feed string, from common.Address, tx *types.Transaction, answer *big.Int, sentAtBlock uint64) error {
+	rec := t.newRecord(feed, from, tx, answer, sentAtBlock)
+	if err := t.db.SaveTransaction(ctx, rec); err != nil {
+		return err
+	}
+	t.publish(rec, nil)
+	return nil
+}
+
+// Replace records replacement as the successor of old, which shares its nonce
+func (t *Tracker) Replace(ctx context.Context, old *db.Transaction, replacement *types.Transaction, sentAtBlock uint64) error {
+	answer, _ := new(big.Int).SetString(old.Answer, 10)
+	rec := t.newRecord(old.Feed, common.HexToAddress(old.FromAddress), replacement, answer, sentAtBlock)
+	if err := t.db.ReplaceTransaction(ctx, old, rec); err != nil {
+		return err
+	}
+	metrics.TxStatus.WithLabelValues(old.Feed, old.Status).Inc()
+	t.publish(old, nil)
+	t.publish(rec, nil)
+	return nil
+}
+
+// Stuck returns the pending transactions of a feed sent from address that
+// have not been mined within the given number of blocks
+func (t *Tracker) Stuck(ctx context.Context, feed string, from common.Address, blocks uint64) ([]*db.Transaction, error) {
+	txs, err := t.db.ListOpenTransactions(ctx, t.chain)
+	if err != nil || len(txs) == 0 {
+		return nil, err
+	}
+
+	head, err := t.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stuck []*db.Transaction
+	for _, rec := range txs {
+		if rec.Feed != feed || rec.FromAddress != from.Hex() || rec.Status != db.TxPending {
+			continue
+		}
+		if rec.SentAtBlock+blocks <= head {
+			stuck = append(stuck, rec)
+		}
+	}
+	return stuck, nil
+}
+
+// NextNonce returns one past the highest nonce of the open transactions sent
+// from address on this chain. ok is false if there are none.
+func (t *Tracker) NextNonce(ctx context.Context, from common.Address) (next uint64, ok bool, err error) {
+	txs, err := t.db.ListOpenTransactions(ctx, t.chain)
+	if err != nil {
+		return 0, false, err
+	}
+
+	for _, rec := range txs {
+		if rec.FromAddress == from.Hex() {
+			next, ok = max(next, rec.Nonce+1), true
+		}
+	}
+	return next, ok, nil
+}
+
+func (t *Tracker) newRecord(feed string, from common.Address, tx *types.Transaction, answer *big.Int, sentAtBlock uint64) *db.Transaction {
+	rec := &db.Transaction{
+		TxHash:      tx.Hash().Hex(),
+		Feed:        feed,
+		Chain:       t.chain,
+		FromAddress: from.Hex(),
+		Nonce:       tx.Nonce(),
+		Answer:      answer.String(),
+		Input:       hexutil.Encode(tx.Data()),
+		GasLimit:    tx.Gas(),
+		GasFeeCap:   tx.GasFeeCap().String(),
+		GasTipCap:   tx.GasTipCap().String(),
+		SentAtBlock: sentAtBlock,
+		Status:      db.TxPending,
+	}
+	if tx.To() != nil {
+		rec.ToAddress = tx.To().Hex()
+	}
+	return rec
+}
+
+// Wait polls until the transaction reaches a final status or ctx is done. If
+// the transaction is replaced by a fee bump, Wait follows the replacement; if
+// a replacement is dropped because a transaction it replaced was mined after
+// all, Wait goes back to that one. It returns the last known record, plus the
+// receipt if the transaction was mined.
+func (t *Tracker) Wait(ctx context.Context, txHash common.Hash) (*db.Transaction, *types.Receipt, error) {
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		rec, err := t.db.GetTransaction(ctx, txHash.Hex())
+		if err != nil {
+			return nil, nil, err
+		}
+		if rec == nil {
+			return nil, nil, errors.New("transaction is not tracked")
+		}
+
+		head, err := t.client.BlockNumber(ctx)
+		if err != nil {
+			return rec, nil, err
+		}
+
+		receipt, err := t.refresh(ctx, rec, head)
+		if err != nil {
+			return rec, nil, err
+		}
+		if rec.ReplacedBy != "" && (rec.Status == db.TxReplaced || rec.Status == db.TxDropped) {
+			txHash = common.HexToHash(rec.ReplacedBy)
+			continue
+		}
+		if rec.Status == db.TxDropped && rec.Replaces != "" {
+			mined, err := t.minedPredecessor(ctx, rec)
+			if err != nil {
+				return rec, nil, err
+			}
+			if mined != nil {
+				slog.InfoContext(ctx, "replaced transaction was mined instead", "chain", t.chain, "tx", mined.TxHash, "dropped", rec.TxHash)
+
+				// Reopen it, as it may have been marked dropped, so refresh
+				// records the receipt
+				mined.Status = db.TxPending
+				mined.Error = ""
+				if _, err := t.refresh(ctx, mined, head); err != nil {
+					return mined, nil, err
+				}
+				txHash = common.HexToHash(mined.TxHash)
+				continue
+			}
+		}
+		if rec.IsFinal() {
+			// The record may have been finalized by Run, in which case
+			// refresh did not fetch the receipt
+			if receipt == nil && rec.Status != db.TxDropped {
+				receipt, err = t.client.TransactionReceipt(ctx, txHash)
+			}
+			return rec, receipt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return rec, receipt, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// minedPredecessor returns the transaction rec replaced, directly or through
+// earlier fee bumps, that was mined, or nil if none was
+func (t *Tracker) minedPredecessor(ctx context.Context, rec *db.Transaction) (*db.Transaction, error) {
+	for rec.Replaces != "" {
+		prev, err := t.db.GetTransaction(ctx, rec.Replaces)
+		if err != nil || prev == nil {
+			return nil, err
+		}
+
+		_, err = t.client.TransactionReceipt(ctx, common.HexToHash(prev.TxHash))
+		if err == nil {
+			return prev, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+		rec = prev
+	}
+	return nil, nil
+}
+
+// Run refreshes every open transaction on each poll until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := t.poll(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "transaction poll failed", "chain", t.chain, "err", err)
+			}
+		}
+	}
+}
+
+// poll refreshes all open transactions against the current head
+func (t *Tracker) poll(ctx context.Context) error {
+	txs, err := t.db.ListOpenTransactions(ctx, t.chain)
+	if err != nil || len(txs) == 0 {
+		return err
+	}
+
+	head, err := t.client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range txs {
+		if _, err := t.refresh(ctx, rec, head); err != nil {
+			slog.ErrorContext(ctx, "failed to refresh transaction", "chain", t.chain, "tx", rec.TxHash, "err", err)
+		}
+	}
+	return nil
+}
+
+// refresh updates rec from the chain and saves it if anything changed
+func (t *Tracker) refresh(ctx context.Context, rec *db.Transaction, head uint64) (*types.Receipt, error) {
+	if rec.IsFinal() {
+		return nil, nil
+	}
+
+	before := *rec
+	txHash := common.HexToHash(rec.TxHash)
+
+	receipt, err := t.client.TransactionReceipt(ctx, txHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return nil, err
+	}
+
+	if receipt == nil {
+		if rec.Status == db.TxMined {
+			slog.WarnContext(ctx, "transaction reorged out", "chain", t.chain, "tx", rec.TxHash, "block", rec.BlockNumber)
+			rec.Status = db.TxPending
+			rec.BlockNumber = 0
+			rec.BlockHash = ""
+			rec.Confirmations = 0
+			rec.GasUsed = 0
+		}
+
+		dropped, err := t.isDropped(ctx, rec)
+		if err != nil {
+			return nil, err
+		}
+		if dropped {
+			rec.Status = db.TxDropped
+			if rec.ReplacedBy != "" {
+				rec.Error = "replaced by " + rec.ReplacedBy
+			}
+		}
+	} else {
+		blockNumber := receipt.BlockNumber.Uint64()
+		if rec.BlockHash != "" && rec.BlockHash != receipt.BlockHash.Hex() {
+			slog.WarnContext(ctx, "transaction moved after a reorg", "chain", t.chain, "tx", rec.TxHash, "from_block", rec.BlockNumber, "to_block", blockNumber)
+		}
+
+		rec.BlockNumber = blockNumber
+		rec.BlockHash = receipt.BlockHash.Hex()
+		rec.GasUsed = receipt.GasUsed
+		rec.Confirmations = 1
+		if head > blockNumber {
+			rec.Confirmations = head - blockNumber + 1
+		}
+
+		switch {
+		case receipt.Status == types.ReceiptStatusFailed:
+			rec.Status = db.TxFailed
+			rec.Error = "execution reverted"
+		case rec.Confirmations >= t.confirmations:
+			rec.Status = db.TxConfirmed
+		default:
+			rec.Status = db.TxMined
+		}
+	}
+
+	if *rec != before {
+		if rec.Status != before.Status {
+			slog.InfoContext(ctx, "transaction status changed", "chain", t.chain, "tx", rec.TxHash, "from", before.Status, "to", rec.Status)
+		}
+		if err := t.db.SaveTransaction(ctx, rec); err != nil {
+			return receipt, err
+		}
+		if rec.Status != before.Status {
+			t.observe(rec)
+			t.publish(rec, receipt)
+		}
+	}
+	return receipt, nil
+}
+
+// observe records a status change in the transaction metrics. Gas is only
+// observed once the transaction is final, so reorgs do not count it twice.
+func (t *Tracker) observe(rec *db.Transaction) {
+	metrics.TxStatus.WithLabelValues(rec.Feed, rec.Status).Inc()
+	if rec.IsFinal() && rec.GasUsed > 0 {
+		metrics.TxGasUsed.WithLabelValues(rec.Feed).Observe(float64(rec.GasUsed))
+	}
+}
+
+// publish announces a status change to subscribers of the transaction
+func (t *Tracker) publish(rec *db.Transaction, receipt *types.Receipt) {
+	if t.bus == nil {
+		return
+	}
+	snapshot := *rec
+	t.bus.Publish(events.TxTopic(rec.TxHash), &events.TxUpdate{Transaction: &snapshot, Receipt: receipt})
+}
+
+// isDropped reports whether a transaction without a receipt will never be
+// mined: either its nonce was consumed by another transaction, or the node has
+// not known about it for longer than the drop timeout
+func (t *Tracker) isDropped(ctx context.Context, rec *db.Transaction) (bool, error) {
+	txHash := common.HexToHash(rec.TxHash)
+
+	nonce, err := t.client.NonceAt(ctx, common.HexToAddress(rec.FromAddress), nil)
+	if err != nil {
+		return false, err
+	}
+	if nonce > rec.Nonce {
+		// The nonce may have been consumed by this very transaction between
+		// the receipt lookup and now, so check once more
+		if _, err := t.client.TransactionReceipt(ctx, txHash); err == nil {
+			return false, nil
+		}
+		rec.Error = "nonce consumed by another transaction"
+		return true, nil
+	}
+
+	if t.dropTimeout <= 0 || time.Since(rec.CreatedAt) < t.dropTimeout {
+		return false, nil
+	}
+
+	_, _, err = t.client.TransactionByHash(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		rec.Error = "not found in mempool"
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,144 @@
+package updater
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FeeConfig controls how update transactions are priced and when they are bumped
+type FeeConfig struct {
+	MaxFeePerGas         *big.Int // cap on the fee cap (or gas price); nil means uncapped
+	MaxPriorityFeePerGas *big.Int // cap on the tip; nil means uncapped
+	GasLimitMargin       uint64   // percent added on top of EstimateGas
+	BumpAfterBlocks      uint64   // resubmit after this many blocks without a receipt; 0 disables bumping
+	BumpPercent          uint64   // fee increase per bump; nodes require at least 10
+	BumpInterval         time.Duration
+}
+
+// minBumpPercent is the smallest increase geth accepts for a replacement
+const minBumpPercent = 10
+
+// txFees holds the fee fields of a transaction. For legacy transactions
+// feeCap and tipCap are both the gas price.
+type txFees struct {
+	feeCap *big.Int
+	tipCap *big.Int
+}
+
+// suggestFees prices a new transaction. With a base fee the fee cap is twice
+// the base fee plus the tip, which survives several full blocks; without one
+// the suggested legacy gas price is used.
+func (u *Updater) suggestFees(ctx context.Context, head *types.Header) (txFees, error) {
+	if head.BaseFee == nil {
+		gasPrice, err := u.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return txFees{}, err
+		}
+		gasPrice = capAt(gasPrice, u.fees.MaxFeePerGas)
+		return txFees{feeCap: gasPrice, tipCap: gasPrice}, nil
+	}
+
+	tip, err := u.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return txFees{}, err
+	}
+	tip = capAt(tip, u.fees.MaxPriorityFeePerGas)
+
+	feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+	feeCap.Add(feeCap, tip)
+	feeCap = capAt(feeCap, u.fees.MaxFeePerGas)
+
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	return txFees{feeCap: feeCap, tipCap: tip}, nil
+}
+
+// bumpFees prices a replacement for a transaction paying old. Both fields must
+// rise by at least BumpPercent; ok is false if the caps do not allow that.
+func (u *Updater) bumpFees(ctx context.Context, head *types.Header, old txFees) (fees txFees, ok bool, err error) {
+	percent := u.fees.BumpPercent
+	if percent < minBumpPercent {
+		percent = minBumpPercent
+	}
+
+	minFeeCap := bumpBy(old.feeCap, percent)
+	minTipCap := bumpBy(old.tipCap, percent)
+
+	suggested, err := u.suggestFees(ctx, head)
+	if err != nil {
+		return txFees{}, false, err
+	}
+
+	fees = txFees{
+		feeCap: capAt(maxOf(minFeeCap, suggested.feeCap), u.fees.MaxFeePerGas),
+		tipCap: maxOf(minTipCap, suggested.tipCap),
+	}
+	if head.BaseFee != nil {
+		fees.tipCap = capAt(fees.tipCap, u.fees.MaxPriorityFeePerGas)
+	} else {
+		fees.tipCap = fees.feeCap
+	}
+
+	if fees.feeCap.Cmp(minFeeCap) < 0 || fees.tipCap.Cmp(minTipCap) < 0 || fees.tipCap.Cmp(fees.feeCap) > 0 {
+		return txFees{}, false, nil
+	}
+	return fees, true, nil
+}
+
+// newTxData builds an unsigned transaction of the type matching fees
+func newTxData(chainID *big.Int, head *types.Header, nonce, gasLimit uint64, to *common.Address, data []byte, fees txFees) types.TxData {
+	if head.BaseFee == nil {
+		return &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.feeCap,
+			Gas:      gasLimit,
+			To:       to,
+			Value:    big.NewInt(0),
+			Data:     data,
+		}
+	}
+
+	return &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.tipCap,
+		GasFeeCap: fees.feeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     big.NewInt(0),
+		Data:      data,
+	}
+}
+
+// withMargin adds percent to gas
+func withMargin(gas, percent uint64) uint64 {
+	return gas + gas*percent/100
+}
+
+// bumpBy returns v increased by percent, rounded up
+func bumpBy(v *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(v, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// capAt returns v, or limit if limit is set and lower
+func capAt(v, limit *big.Int) *big.Int {
+	if limit != nil && limit.Sign() > 0 && v.Cmp(limit) > 0 {
+		return new(big.Int).Set(limit)
+	}
+	return v
+}
+
+// maxOf returns the larger of a and b
+func maxOf(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -2,97 +2,301 @@ package updater
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
+	"fmt"
+	"log/slog"
 	"math/big"
+	"time"
 
 	"github.com/114windd/oracle-client/internal/contracts"
+	"github.com/114windd/oracle-client/internal/db"
+	"github.com/114windd/oracle-client/internal/metrics"
+	"github.com/114windd/oracle-client/internal/nonce"
+	"github.com/114windd/oracle-client/internal/retry"
+	"github.com/114windd/oracle-client/internal/signer"
+	"github.com/114windd/oracle-client/internal/tracing"
+	"github.com/114windd/oracle-client/internal/txtracker"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Updater handles updating the MockOracle contract
 type Updater struct {
-	client     *ethclient.Client
-	oracle     *contracts.MockOracle
-	privateKey *ecdsa.PrivateKey
-	chainID    *big.Int
+	client          *ethclient.Client
+	oracle          *contracts.MockOracle
+	abi             *abi.ABI
+	contractAddress common.Address
+	signer          signer.Signer
+	address         common.Address
+	chainID         *big.Int
+	feed            string
+	tracker         *txtracker.Tracker
+	nonces          *nonce.Manager
+	fees            FeeConfig
 }
 
-// NewUpdater creates a new updater instance
-func NewUpdater(client *ethclient.Client, contractAddress common.Address, privateKeyHex string) (*Updater, error) {
+// NewUpdater creates a new updater instance for the named feed, sending
+// transactions signed by txSigner. Every sent transaction is handed to
+// tracker, and nonces come from the signer's manager in the chain's nonce
+// registry.
+func NewUpdater(client *ethclient.Client, contractAddress common.Address, txSigner signer.Signer, feed string, tracker *txtracker.Tracker, nonces *nonce.Registry, fees FeeConfig) (*Updater, error) {
 	oracle, err := contracts.NewMockOracle(contractAddress, client)
 	if err != nil {
 		return nil, err
 	}
 
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	oracleABI, err := contracts.MockOracleMetaData.GetAbi()
 	if err != nil {
 		return nil, err
 	}
 
-	chainID, err := client.NetworkID(context.Background())
+	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
+	address := txSigner.Address()
+
 	return &Updater{
-		client:     client,
-		oracle:     oracle,
-		privateKey: privateKey,
-		chainID:    chainID,
+		client:          client,
+		oracle:          oracle,
+		abi:             oracleABI,
+		contractAddress: contractAddress,
+		signer:          txSigner,
+		address:         address,
+		chainID:         chainID,
+		feed:            feed,
+		tracker:         tracker,
+		nonces:          nonces.For(address),
+		fees:            fees,
 	}, nil
 }
 
-// UpdatePrice updates the oracle with a new price
-func (u *Updater) UpdatePrice(ctx context.Context, newAnswer *big.Int) (common.Hash, error) {
-	// Get the public key from the private key
-	publicKey := u.privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return common.Hash{}, errors.New("failed to get public key")
-	}
-
-	// Get the address from the public key
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+// UpdatePrice updates the oracle with a new price. It sends an EIP-1559
+// transaction when the chain has a base fee and a legacy one otherwise.
+func (u *Updater) UpdatePrice(ctx context.Context, newAnswer *big.Int) (_ common.Hash, err error) {
+	ctx, span := tracing.Start(ctx, "updater.updateAnswer",
+		attribute.String("feed", u.feed),
+		attribute.String("contract.address", u.contractAddress.Hex()),
+		attribute.String("answer", newAnswer.String()),
+	)
+	defer func() { tracing.End(span, err) }()
 
-	// Get the nonce
-	nonce, err := u.client.NonceAt(ctx, fromAddress, nil)
+	input, err := u.abi.Pack("updateAnswer", newAnswer)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
-	// Get gas price
-	gasPrice, err := u.client.SuggestGasPrice(ctx)
+	// Estimate gas, plus a safety margin
+	gasLimit, err := u.client.EstimateGas(ctx, ethereum.CallMsg{
+		From: u.address,
+		To:   &u.contractAddress,
+		Data: input,
+	})
 	if err != nil {
 		return common.Hash{}, err
 	}
+	gasLimit = withMargin(gasLimit, u.fees.GasLimitMargin)
 
-	// Create the transaction options
-	auth, err := bind.NewKeyedTransactorWithChainID(u.privateKey, u.chainID)
+	head, err := u.client.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = 300000 // Set a reasonable gas limit
-	auth.GasPrice = gasPrice
+	fees, err := u.suggestFees(ctx, head)
+	if err != nil {
+		return common.Hash{}, err
+	}
 
-	// Call the updateAnswer function
-	tx, err := u.oracle.UpdateAnswer(auth, newAnswer)
+	// Sign and send with the next nonce for this signer
+	var tx *types.Transaction
+	err = u.nonces.Do(ctx, func(nonce uint64) error {
+		signed, err := u.signer.SignTx(ctx, types.NewTx(newTxData(u.chainID, head, nonce, gasLimit, &u.contractAddress, input, fees)), u.chainID)
+		if err != nil {
+			return err
+		}
+		if err := u.client.SendTransaction(ctx, signed); err != nil {
+			err = retry.SendFailed(err)
+			if retry.IsAmbiguous(err) {
+				// The node may have accepted it, so track it like a sent
+				// transaction: its nonce stays reserved and the tracker
+				// finds out whether it was mined, or the bumper resends it
+				u.track(ctx, signed, newAnswer, head)
+			}
+			return err
+		}
+		tx = signed
+		return nil
+	})
 	if err != nil {
 		return common.Hash{}, err
 	}
+	metrics.TxSent.WithLabelValues(u.feed, "new").Inc()
+	span.SetAttributes(attribute.String("tx.hash", tx.Hash().Hex()), attribute.Int64("tx.nonce", int64(tx.Nonce())))
+	slog.InfoContext(ctx, "sent update transaction", "feed", u.feed, "tx", tx.Hash().Hex(), "nonce", tx.Nonce(), "answer", newAnswer)
 
+	u.track(ctx, tx, newAnswer, head)
 	return tx.Hash(), nil
 }
 
+// track records a broadcast transaction. It may already be in the mempool,
+// so a tracking failure must not be reported as a failed update.
+func (u *Updater) track(ctx context.Context, tx *types.Transaction, answer *big.Int, head *types.Header) {
+	if err := u.tracker.Track(ctx, u.feed, u.address, tx, answer, head.Number.Uint64()); err != nil {
+		slog.ErrorContext(ctx, "failed to record update transaction", "feed", u.feed, "tx", tx.Hash().Hex(), "err", err)
+	}
+}
+
+// Run reports the signer balance and, unless bumping is disabled, resubmits
+// this feed's stuck transactions with higher fees until ctx is cancelled
+func (u *Updater) Run(ctx context.Context) error {
+	u.recordBalance(ctx)
+
+	interval := u.fees.BumpInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			u.recordBalance(ctx)
+			if u.fees.BumpAfterBlocks == 0 {
+				continue
+			}
+			if err := u.bumpStuck(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "fee bump failed", "feed", u.feed, "err", err)
+			}
+		}
+	}
+}
+
+// recordBalance publishes the signer's balance in ether
+func (u *Updater) recordBalance(ctx context.Context) {
+	balance, err := u.client.BalanceAt(ctx, u.address, nil)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.WarnContext(ctx, "failed to read signer balance", "feed", u.feed, "err", err)
+		}
+		return
+	}
+
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(params.Ether)).Float64()
+	metrics.SignerBalance.WithLabelValues(u.feed, u.address.Hex()).Set(eth)
+}
+
+// bumpStuck replaces every transaction not mined within BumpAfterBlocks with
+// one that has the same nonce and calldata but higher fees
+func (u *Updater) bumpStuck(ctx context.Context) error {
+	stuck, err := u.tracker.Stuck(ctx, u.feed, u.address, u.fees.BumpAfterBlocks)
+	if err != nil || len(stuck) == 0 {
+		return err
+	}
+
+	head, err := u.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range stuck {
+		if err := u.replace(ctx, head, rec); err != nil {
+			slog.ErrorContext(ctx, "failed to replace transaction", "feed", u.feed, "tx", rec.TxHash, "err", err)
+		}
+	}
+	return nil
+}
+
+// replace resubmits rec with bumped fees and records the replacement
+func (u *Updater) replace(ctx context.Context, head *types.Header, rec *db.Transaction) (err error) {
+	ctx, span := tracing.Start(ctx, "updater.replace",
+		attribute.String("feed", u.feed),
+		attribute.String("tx.hash", rec.TxHash),
+		attribute.Int64("tx.nonce", int64(rec.Nonce)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	oldFees, err := parseFees(rec)
+	if err != nil {
+		return err
+	}
+
+	fees, ok, err := u.bumpFees(ctx, head, oldFees)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.WarnContext(ctx, "cannot bump transaction, fee caps reached", "feed", u.feed, "tx", rec.TxHash)
+		return nil
+	}
+
+	input, err := hexutil.Decode(rec.Input)
+	if err != nil {
+		return err
+	}
+	to := common.HexToAddress(rec.ToAddress)
+
+	signed, err := u.signer.SignTx(ctx, types.NewTx(newTxData(u.chainID, head, rec.Nonce, rec.GasLimit, &to, input, fees)), u.chainID)
+	if err != nil {
+		return err
+	}
+	if err := u.client.SendTransaction(ctx, signed); err != nil {
+		return err
+	}
+	metrics.TxSent.WithLabelValues(u.feed, "replacement").Inc()
+
+	slog.InfoContext(ctx, "replaced transaction", "feed", u.feed, "tx", rec.TxHash, "replacement", signed.Hash().Hex(),
+		"nonce", rec.Nonce, "fee_cap", fees.feeCap, "tip_cap", fees.tipCap)
+
+	return u.tracker.Replace(ctx, rec, signed, head.Number.Uint64())
+}
+
+// parseFees reads the fee fields stored with a transaction record
+func parseFees(rec *db.Transaction) (txFees, error) {
+	feeCap, ok := new(big.Int).SetString(rec.GasFeeCap, 10)
+	if !ok {
+		return txFees{}, fmt.Errorf("invalid stored fee cap %q", rec.GasFeeCap)
+	}
+	tipCap, ok := new(big.Int).SetString(rec.GasTipCap, 10)
+	if !ok {
+		return txFees{}, fmt.Errorf("invalid stored tip cap %q", rec.GasTipCap)
+	}
+	return txFees{feeCap: feeCap, tipCap: tipCap}, nil
+}
+
+// WaitForTransaction blocks until the transaction is confirmed, failed or
+// dropped, or ctx is done
+func (u *Updater) WaitForTransaction(ctx context.Context, txHash common.Hash) (*db.Transaction, *types.Receipt, error) {
+	return u.tracker.Wait(ctx, txHash)
+}
+
+// ParseAnswerUpdated extracts the AnswerUpdated event emitted by the oracle
+// from a receipt
+func (u *Updater) ParseAnswerUpdated(receipt *types.Receipt) (*contracts.MockOracleAnswerUpdated, error) {
+	for _, l := range receipt.Logs {
+		if event, err := u.oracle.ParseAnswerUpdated(*l); err == nil {
+			return event, nil
+		}
+	}
+	return nil, errors.New("no AnswerUpdated event in receipt")
+}
+
 // GetOwner retrieves the owner of the oracle contract
 func (u *Updater) GetOwner(ctx context.Context) (common.Address, error) {
-	return u.oracle.Owner(&bind.CallOpts{Context: ctx})
+	ctx, span := tracing.Start(ctx, "updater.owner", attribute.String("contract.address", u.contractAddress.Hex()))
+	owner, err := u.oracle.Owner(&bind.CallOpts{Context: ctx})
+	tracing.End(span, err)
+	return owner, err
 }
 
 // IsOwner checks if the current address is the owner
@@ -102,12 +306,5 @@ func (u *Updater) IsOwner(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
-	publicKey := u.privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return false, errors.New("failed to get public key")
-	}
-
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	return fromAddress == owner, nil
+	return u.address == owner, nil
 }